@@ -1,38 +1,39 @@
 package cmd
 
 import (
-	"fmt"
-	"io"
-	"os"
-
-	"github.com/monster0506/bashutils-go/internal/utils"
+	"github.com/monster0506/bashutils-go/pkg/coreutils"
 	"github.com/spf13/cobra"
 )
 
-var catCmd = &cobra.Command{
-	Use:   "cat [file]",
-	Short: "Concatenate and display files",
-	Args:  cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		// Expand glob patterns in arguments
-		expandedArgs, err := utils.ExpandGlobsForReading(args)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "cat: %v\n", err)
-			return
-		}
-		
-		for _, path := range expandedArgs {
-			data, err := os.ReadFile(path)
+func newCatCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cat [files...]",
+		Short: "Concatenate and display files",
+		Long: `Concatenate and display files.
+
+Called as zcat, or with --decompress, compressed files (gzip, bzip2,
+zlib and compress .Z) are read as their decompressed content.`,
+		Aliases: []string{"zcat"},
+		Args:    cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			src, err := newLineSource(cmd, args)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "cat: %v\n", err)
-				continue
+				return err
 			}
-			func() (n int, err error) {
-				if sw, ok := io.Writer(os.Stdout).(io.StringWriter); ok {
-					return sw.WriteString(string(data))
+			defer src.Close()
+			src.Binary = true
+
+			for src.NextFile() {
+				err := coreutils.Cat(cmd.Context(), src.Reader(), out)
+				if err := fileError(src, err); err != nil {
+					return err
 				}
-				return io.Writer(os.Stdout).Write([]byte(string(data)))
-			}()
-		}
-	},
+			}
+
+			return sourceStatus(src)
+		},
+	}
+
+	return cmd
 }
@@ -1,43 +1,28 @@
 package cmd
 
 import (
-	"fmt"
-	"strings"
-
-	"github.com/monster0506/bashutils-go/internal/utils"
+	"github.com/monster0506/bashutils-go/pkg/coreutils"
 	"github.com/spf13/cobra"
 )
 
-var echoCmd = &cobra.Command{
-	Use:   "echo [strings...]",
-	Short: "Echo arguments to standard output",
-	Args:  cobra.ArbitraryArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		suppressNewline, _ := cmd.Flags().GetBool("newline")
-		enableEscape, _ := cmd.Flags().GetBool("escape")
-		expandEnv, _ := cmd.Flags().GetBool("expand-env")
+func newEchoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "echo [strings...]",
+		Short: "Echo arguments to standard output",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opts coreutils.EchoOptions
+			opts.NoNewline, _ = cmd.Flags().GetBool("newline")
+			opts.Escapes, _ = cmd.Flags().GetBool("escape")
+			opts.ExpandEnv, _ = cmd.Flags().GetBool("expand-env")
 
-		out := strings.Join(args, " ")
-		
-		if expandEnv {
-			out = utils.ExpandEnvironmentVariables(out)
-		}
-		
-		if enableEscape {
-			out = strings.ReplaceAll(out, "\\n", "\n")
-			out = strings.ReplaceAll(out, "\\t", "\t")
-		}
+			return coreutils.Echo(cmd.OutOrStdout(), args, opts)
+		},
+	}
 
-		if suppressNewline {
-			fmt.Print(out)
-		} else {
-			fmt.Println(out)
-		}
-	},
-}
+	cmd.Flags().BoolP("newline", "n", false, "do not output the trailing newline")
+	cmd.Flags().BoolP("escape", "e", false, "enable interpretation of backslash escapes")
+	cmd.Flags().BoolP("expand-env", "E", false, "expand environment variables ($VAR and %VAR%)")
 
-func init() {
-	echoCmd.Flags().BoolP("newline", "n", false, "do not output the trailing newline")
-	echoCmd.Flags().BoolP("escape", "e", false, "enable interpretation of backslash escapes")
-	echoCmd.Flags().BoolP("expand-env", "E", false, "expand environment variables ($VAR and %VAR%)")
+	return cmd
 }
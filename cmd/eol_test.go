@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles writes the named files, with their content, to a temporary
+// directory and returns their paths in the order given.
+func writeFiles(t *testing.T, files ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i+1 < len(files); i += 2 {
+		path := filepath.Join(dir, files[i])
+		if err := os.WriteFile(path, []byte(files[i+1]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestPasteEOL(t *testing.T) {
+	p := writeFiles(t, "a.crlf", "a\r\nc\r\n", "b.crlf", "b\r\nd\r\n", "x.lf", "x\ny\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"CRLF files", []string{"paste", p[0], p[1]}, "a\tb\r\nc\td\r\n"},
+		{"first file decides", []string{"paste", p[2], p[0]}, "x\ta\ny\tc\n"},
+		{"CRLF first", []string{"paste", p[0], p[2]}, "a\tx\r\nc\ty\r\n"},
+		{"eol lf", []string{"paste", "--eol", "lf", p[0], p[1]}, "a\tb\nc\td\n"},
+		{"keep-cr", []string{"paste", "--keep-cr", "--eol", "lf", p[0], p[2]}, "a\r\tx\nc\r\ty\n"},
+	}
+	for _, tt := range tests {
+		got, stderr, status := run(t, "", tt.args...)
+		if status != 0 {
+			t.Errorf("%s: exit status %d: %s", tt.name, status, stderr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
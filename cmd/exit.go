@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// exitError carries a specific exit status out of a command's RunE. If err
+// is nil the problem has already been reported and nothing more is printed.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns an error that makes the command exit with code without
+// printing a message.
+func exitCode(code int) error {
+	return &exitError{code: code}
+}
+
+// exitWith returns an error that prints err and makes the command exit with
+// code.
+func exitWith(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// exitStatus returns the exit status a command's error maps to: 0 for nil,
+// the carried code for an exitError, statusBrokenPipe when the output's
+// reader went away and 1 for anything else.
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	if isBrokenPipe(err) {
+		return statusBrokenPipe
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return 1
+}
+
+// errorMessage returns the message to print for err, or "" if it has
+// already been reported or needs no report, like a broken pipe.
+func errorMessage(err error) string {
+	if isBrokenPipe(err) {
+		return ""
+	}
+	var ee *exitError
+	if errors.As(err, &ee) && ee.err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// usageError adds a pointer to cmd's help to err, for mistakes in how the
+// command was invoked.
+func usageError(cmd *cobra.Command, err error) error {
+	return fmt.Errorf("%v\nTry '%s --help' for more information.", err, cmd.CommandPath())
+}
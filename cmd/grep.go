@@ -1,108 +1,335 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"github.com/monster0506/bashutils-go/internal/utils"
+	"io"
 	"os"
-	"regexp"
+	"strings"
 
+	"github.com/monster0506/bashutils-go/internal/utils"
+	"github.com/monster0506/bashutils-go/pkg/coreutils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-var grepCmd = &cobra.Command{
-	Use:   "grep [pattern] [files...]",
-	Short: "Print lines matching a pattern",
-	Args:  cobra.ArbitraryArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		patternStr := args[0]
-		filePath := args[1]
-
-		ignoreCase, _ := cmd.Flags().GetBool("ignore-case")
-		invertMatch, _ := cmd.Flags().GetBool("invert-match")
-		lineNumber, _ := cmd.Flags().GetBool("line-number")
-		regexpFlag, _ := cmd.Flags().GetString("regexp")
-
-		if regexpFlag != "" {
-			patternStr = regexpFlag
-		}
+func newGrepCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grep [pattern] [files...]",
+		Short: "Print lines matching a pattern",
+		Long: `Print lines matching a pattern.
 
-		if ignoreCase {
-			patternStr = "(?i)" + patternStr // Add case-insensitive flag to regex
-		}
+Patterns are Go (RE2) regular expressions unless -G, -E or -F makes them
+POSIX basic or extended regular expressions or fixed strings; egrep and
+fgrep stand for grep -E and grep -F. Several patterns can be given with
+-e, or one per line in a file with -f, and a line matches if any of them
+does. -w and -x only count matches that are whole words or whole lines.
 
-		re, err := regexp.Compile(patternStr)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "grep: invalid regex pattern: %v\n", err)
-			return
-		}
+With more than one file, or when searching directories with -r or -R,
+each line is prefixed with the name of its file, as in "path:line".
+--include, --exclude and --exclude-dir take glob patterns, matched
+against base names, that select the files searched. -H and -h force the
+name prefix on or off.
 
-		if len(args) < 2 {
-			// Read from stdin when no files provided
-			scanner := bufio.NewScanner(os.Stdin)
-			for scanner.Scan() {
-				line := scanner.Text()
-				match := re.MatchString(line)
-				if (match && !invertMatch) || (!match && invertMatch) {
-					if lineNumber {
-						fmt.Printf("%d:%s\n", 0, line)
-					} else {
-						fmt.Println(line)
-					}
-				}
+-c, -l, -L and -q print a count per file, the names of files with or
+without a match, or nothing at all; with -q the exit status alone tells
+whether a line was selected, and grep stops at the first one. -o prints
+each match on a line of its own. -m NUM stops reading a file after NUM
+selected lines.
+
+-A, -B and -C print lines of context after, before or around each selected
+line. Context lines have "-" instead of ":" after their prefixes, and
+groups of lines that are not adjacent are separated by "--".
+
+Called as zgrep, or with --decompress, compressed files (gzip, bzip2,
+zlib and compress .Z) are read as their decompressed content.`,
+		Aliases: []string{"zgrep", "egrep", "fgrep"},
+		Args:    cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+
+			var opts coreutils.GrepOptions
+			patterns, operands, err := grepPatterns(cmd, args)
+			if err != nil {
+				return exitWith(2, err)
 			}
-			return
-		}
+			opts.Patterns = patterns
+			if err := grepSyntax(cmd, &opts); err != nil {
+				return exitWith(2, usageError(cmd, err))
+			}
+			opts.WordRegexp, _ = cmd.Flags().GetBool("word-regexp")
+			opts.LineRegexp, _ = cmd.Flags().GetBool("line-regexp")
+			opts.IgnoreCase, _ = cmd.Flags().GetBool("ignore-case")
+			opts.InvertMatch, _ = cmd.Flags().GetBool("invert-match")
+			opts.LineNumber, _ = cmd.Flags().GetBool("line-number")
+			opts.ByteOffset, _ = cmd.Flags().GetBool("byte-offset")
+			opts.OnlyMatching, _ = cmd.Flags().GetBool("only-matching")
+			opts.Count, _ = cmd.Flags().GetBool("count")
+			opts.FilesWithMatches, _ = cmd.Flags().GetBool("files-with-matches")
+			opts.FilesWithoutMatch, _ = cmd.Flags().GetBool("files-without-match")
+			opts.Quiet, _ = cmd.Flags().GetBool("quiet")
+			opts.Null, _ = cmd.Flags().GetBool("null")
+			opts.ZeroTerminated, _ = cmd.Flags().GetBool("zero-terminated")
 
-		// Expand glob patterns in file argument
-		expandedFiles, err := utils.ExpandGlobsForReading([]string{filePath})
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "grep: %v\n", err)
-			return
-		}
+			if opts.FilesWithMatches && opts.FilesWithoutMatch {
+				return exitWith(2, usageError(cmd, fmt.Errorf("options '-lL' are incompatible")))
+			}
+			if err := grepContext(cmd, &opts); err != nil {
+				return exitWith(2, usageError(cmd, err))
+			}
+			if cmd.Flags().Changed("max-count") {
+				opts.MaxCount, _ = cmd.Flags().GetInt("max-count")
+				if opts.MaxCount == 0 {
+					return exitCode(1) // nothing can be selected
+				}
+				if opts.MaxCount < 0 {
+					opts.MaxCount = 0
+				}
+			}
 
-		for _, path := range expandedFiles {
-			if len(expandedFiles) > 1 {
-				fmt.Printf("==> %s <==\n", path)
+			files, err := grepFiles(cmd, operands)
+			if err != nil {
+				return exitWith(2, err)
+			}
+			opts.WithFilename = files.withNames
+			if set, _ := cmd.Flags().GetBool("with-filename"); set {
+				opts.WithFilename = true
+			}
+			if set, _ := cmd.Flags().GetBool("no-filename"); set {
+				opts.WithFilename = false
 			}
 
-			file, err := os.Open(path)
+			grepper, err := coreutils.NewGrepper(opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "grep: %v\n", err)
-				continue
+				return exitWith(2, err)
 			}
-			defer file.Close()
-
-			scanner := bufio.NewScanner(file)
-			lineNum := 0
-			for scanner.Scan() {
-				lineNum++
-				line := scanner.Text()
-				match := re.MatchString(line)
-
-				if (match && !invertMatch) || (!match && invertMatch) {
-					if lineNumber {
-						fmt.Printf("%d:%s\n", lineNum, line)
-					} else {
-						fmt.Println(line)
+			src := utils.NewLineSourceFiles(files.names)
+			setupLineSource(cmd, src)
+			defer src.Close()
+
+			// Exit status follows GNU grep: 0 if a line was selected, 1 if none
+			// was, and 2 if an error occurred. With -q a selected line wins
+			// over errors, and nothing more is read after it.
+			selected := false
+			for src.NextFile() {
+				n, err := grepper.GrepFile(cmd.Context(), src.Reader(), out, grepLabel(src.Name()))
+				if err := fileError(src, err); err != nil {
+					return err
+				}
+				if n > 0 {
+					selected = true
+					if opts.Quiet {
+						return nil
 					}
 				}
 			}
 
-			if err := scanner.Err(); err != nil {
-				fmt.Fprintf(os.Stderr, "grep: reading input: %v\n", err)
+			if src.Failed() || files.failed {
+				return exitCode(2)
+			}
+			if !selected {
+				return exitCode(1)
 			}
+			return nil
+		},
+	}
+
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return exitWith(2, usageError(cmd, err))
+	})
+	cmd.Flags().BoolP("ignore-case", "i", false, "ignore case distinctions")
+	cmd.Flags().BoolP("invert-match", "v", false, "select non-matching lines")
+	cmd.Flags().BoolP("line-number", "n", false, "show line numbers")
+	cmd.Flags().BoolP("byte-offset", "b", false, "show the byte offset of each line (or match with -o)")
+	cmd.Flags().BoolP("with-filename", "H", false, "print the file name for each match")
+	cmd.Flags().BoolP("no-filename", "h", false, "never print file names")
+	// -h is --no-filename, so --help goes without a shorthand.
+	cmd.Flags().Bool("help", false, "help for grep")
+	cmd.Flags().BoolP("only-matching", "o", false, "show only the matching parts of lines")
+	cmd.Flags().BoolP("count", "c", false, "print only a count of selected lines per file")
+	cmd.Flags().BoolP("files-with-matches", "l", false, "print only names of files with selected lines")
+	cmd.Flags().BoolP("files-without-match", "L", false, "print only names of files without selected lines")
+	cmd.Flags().BoolP("quiet", "q", false, "print nothing; exit 0 on the first selected line (also --silent)")
+	cmd.Flags().IntP("max-count", "m", 0, "stop reading a file after `NUM` selected lines")
+	cmd.Flags().BoolP("null", "Z", false, "print NUL after file names")
+	cmd.Flags().IntP("after-context", "A", 0, "print `NUM` lines of trailing context")
+	cmd.Flags().IntP("before-context", "B", 0, "print `NUM` lines of leading context")
+	cmd.Flags().IntP("context", "C", 0, "print `NUM` lines of output context")
+	cmd.Flags().StringArrayP("regexp", "e", nil, "use `PATTERN` for matching; can be given several times")
+	cmd.Flags().StringArrayP("file", "f", nil, "take patterns from `FILE`, one per line")
+	cmd.Flags().BoolP("basic-regexp", "G", false, "patterns are POSIX basic regular expressions")
+	cmd.Flags().BoolP("extended-regexp", "E", false, "patterns are POSIX extended regular expressions")
+	cmd.Flags().BoolP("fixed-strings", "F", false, "patterns are fixed strings")
+	cmd.Flags().BoolP("word-regexp", "w", false, "match only whole words")
+	cmd.Flags().BoolP("line-regexp", "x", false, "match only whole lines")
+	cmd.Flags().BoolP("zero-terminated", "z", false, "line delimiter is NUL, not newline")
+	cmd.Flags().BoolP("recursive", "r", false, "search directories recursively, skipping symbolic links inside them")
+	cmd.Flags().BoolP("dereference-recursive", "R", false, "search directories recursively, following all symbolic links")
+	cmd.Flags().StringArray("include", nil, "search only files whose base name matches `GLOB`")
+	cmd.Flags().StringArray("exclude", nil, "skip files whose base name matches `GLOB`")
+	cmd.Flags().StringArray("exclude-dir", nil, "skip directories whose base name matches `GLOB`")
 
-			if len(expandedFiles) > 1 && path != expandedFiles[len(expandedFiles)-1] {
-				fmt.Println()
+	cmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "silent" {
+			name = "quiet"
+		}
+		return pflag.NormalizedName(name)
+	})
+
+	return cmd
+}
+
+// grepPatterns returns the patterns given with -e and -f, or else the
+// first operand, along with the remaining operands, which are files.
+func grepPatterns(cmd *cobra.Command, args []string) (patterns, operands []string, err error) {
+	// GetStringArray would turn -e '' into no pattern at all.
+	exprs := cmd.Flags().Lookup("regexp").Value.(pflag.SliceValue).GetSlice()
+	files, _ := cmd.Flags().GetStringArray("file")
+	if len(exprs) == 0 && len(files) == 0 {
+		if len(args) == 0 {
+			return nil, nil, usageError(cmd, fmt.Errorf("no pattern given"))
+		}
+		return args[:1], args[1:], nil
+	}
+
+	patterns = append([]string{}, exprs...)
+	keepCR, _ := cmd.Flags().GetBool("keep-cr")
+	for _, name := range files {
+		f, err := utils.OpenInput(name, cmd.InOrStdin(), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := io.ReadAll(utils.NewTextReader(f, keepCR))
+		f.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		// An empty file holds no patterns at all, rather than an empty one.
+		if len(data) > 0 {
+			text := strings.TrimSuffix(string(data), "\n")
+			patterns = append(patterns, strings.Split(text, "\n")...)
+		}
+	}
+	return patterns, args, nil
+}
+
+// grepSyntaxes are the flags that choose the syntax of grep patterns.
+var grepSyntaxes = []struct {
+	name   string
+	syntax coreutils.GrepSyntax
+}{
+	{"basic-regexp", coreutils.GrepBasic},
+	{"extended-regexp", coreutils.GrepExtended},
+	{"fixed-strings", coreutils.GrepFixed},
+}
+
+// grepSyntax sets the Syntax option from -G, -E and -F, or from the name
+// grep is called by, egrep or fgrep.
+func grepSyntax(cmd *cobra.Command, opts *coreutils.GrepOptions) error {
+	set := false
+	switch cmd.CalledAs() {
+	case "egrep":
+		opts.Syntax = coreutils.GrepExtended
+	case "fgrep":
+		opts.Syntax = coreutils.GrepFixed
+	}
+	for _, s := range grepSyntaxes {
+		if on, _ := cmd.Flags().GetBool(s.name); on {
+			if set && opts.Syntax != s.syntax {
+				return fmt.Errorf("conflicting matchers specified")
 			}
+			opts.Syntax, set = s.syntax, true
+		}
+	}
+	return nil
+}
+
+// grepContext sets the BeforeContext and AfterContext options from -A, -B
+// and -C. -A and -B take precedence over -C.
+func grepContext(cmd *cobra.Command, opts *coreutils.GrepOptions) error {
+	for _, name := range []string{"context", "before-context", "after-context"} {
+		n, _ := cmd.Flags().GetInt(name)
+		if n < 0 {
+			return fmt.Errorf("%d: invalid context length argument", n)
+		}
+	}
+	n, _ := cmd.Flags().GetInt("context")
+	opts.BeforeContext, opts.AfterContext = n, n
+	if cmd.Flags().Changed("before-context") {
+		opts.BeforeContext, _ = cmd.Flags().GetInt("before-context")
+	}
+	if cmd.Flags().Changed("after-context") {
+		opts.AfterContext, _ = cmd.Flags().GetInt("after-context")
+	}
+	return nil
+}
+
+// grepFileList is the list of files grep searches.
+type grepFileList struct {
+	names     []string
+	withNames bool // prefix output lines with the file name
+	failed    bool // some operand could not be searched
+}
+
+// grepFiles expands the file operands of grep into the files to search,
+// walking directories with -r and -R and applying --include, --exclude
+// and --exclude-dir. Problems with operands are reported on cmd's error
+// output.
+func grepFiles(cmd *cobra.Command, operands []string) (*grepFileList, error) {
+	list := &grepFileList{}
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	follow, _ := cmd.Flags().GetBool("dereference-recursive")
+	recursive = recursive || follow
+
+	walk := utils.WalkOptions{FollowLinks: follow}
+	walk.Include, _ = cmd.Flags().GetStringArray("include")
+	walk.Exclude, _ = cmd.Flags().GetStringArray("exclude")
+	walk.ExcludeDir, _ = cmd.Flags().GetStringArray("exclude-dir")
+	walk.OnError = func(path string, err error) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", cmd.Name(), err)
+		list.failed = true
+	}
+	add := func(path string) { list.names = append(list.names, path) }
+
+	if len(operands) == 0 {
+		if recursive {
+			list.withNames = true
+			walk.Walk("", add)
+		} else {
+			add(utils.StdinName)
+		}
+		return list, nil
+	}
+
+	names, err := utils.ExpandGlobs(operands)
+	if err != nil {
+		return nil, err
+	}
+	list.withNames = len(names) > 1
+	for _, name := range names {
+		if name == utils.StdinName {
+			add(name)
+			continue
+		}
+		info, err := os.Stat(name)
+		switch {
+		case err != nil:
+			add(name) // reported when it fails to open
+		case info.IsDir() && recursive:
+			list.withNames = true
+			walk.Walk(name, add)
+		case info.IsDir():
+			walk.OnError(name, fmt.Errorf("%s: Is a directory", name))
+		case walk.Selects(name):
+			add(name)
 		}
-	},
+	}
+	return list, nil
 }
 
-func init() {
-	grepCmd.Flags().BoolP("ignore-case", "i", false, "ignore case distinctions")
-	grepCmd.Flags().BoolP("invert-match", "v", false, "select non-matching lines")
-	grepCmd.Flags().BoolP("line-number", "n", false, "show line numbers")
-	grepCmd.Flags().StringP("regexp", "e", "", "use a specific regex pattern")
+// grepLabel is the name grep shows for the file operand name.
+func grepLabel(name string) string {
+	if name == utils.StdinName {
+		return "(standard input)"
+	}
+	return name
 }
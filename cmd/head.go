@@ -1,58 +1,52 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"github.com/monster0506/bashutils-go/internal/utils"
+
+	"github.com/monster0506/bashutils-go/pkg/coreutils"
 	"github.com/spf13/cobra"
-	"os"
 )
 
-var headCmd = &cobra.Command{
-	Use:   "head [files...]",
-	Short: "Output the first part of files",
-	Args:  cobra.ArbitraryArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		lines, _ := cmd.Flags().GetInt("lines")
-		
-		if len(args) == 0 {
-			// Read from stdin when no files provided
-			scanner := bufio.NewScanner(os.Stdin)
-			for i := 0; i < lines && scanner.Scan(); i++ {
-				fmt.Println(scanner.Text())
-			}
-		} else {
-			// Expand glob patterns in arguments
-			expandedArgs, err := utils.ExpandGlobsForReading(args)
+func newHeadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "head [files...]",
+		Short: "Output the first part of files",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+
+			var opts coreutils.HeadOptions
+			opts.Lines, _ = cmd.Flags().GetInt("lines")
+			opts.ZeroTerminated, _ = cmd.Flags().GetBool("zero-terminated")
+
+			src, err := newLineSource(cmd, args)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "head: %v\n", err)
-				return
+				return err
 			}
-			
-			for _, path := range expandedArgs {
-				if len(expandedArgs) > 1 {
-					fmt.Printf("==> %s <==\n", path)
-				}
-				
-				f, err := os.Open(path)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "head: %v\n", err)
-					continue
-				}
-				defer f.Close()
-				scanner := bufio.NewScanner(f)
-				for i := 0; i < lines && scanner.Scan(); i++ {
-					fmt.Println(scanner.Text())
+			defer src.Close()
+
+			first := true
+			for src.NextFile() {
+				if src.NumFiles() > 1 {
+					if !first {
+						fmt.Fprintln(out)
+					}
+					fmt.Fprintf(out, "==> %s <==\n", src.Name())
 				}
-				
-				if len(expandedArgs) > 1 && path != expandedArgs[len(expandedArgs)-1] {
-					fmt.Println()
+				first = false
+
+				err := coreutils.Head(cmd.Context(), src.Reader(), out, opts)
+				if err := fileError(src, err); err != nil {
+					return err
 				}
 			}
-		}
-	},
-}
 
-func init() {
-	headCmd.Flags().IntP("lines", "n", 10, "number of lines to show from start")
+			return sourceStatus(src)
+		},
+	}
+
+	cmd.Flags().IntP("lines", "n", 10, "number of lines to show from start")
+	cmd.Flags().BoolP("zero-terminated", "z", false, "line delimiter is NUL, not newline")
+
+	return cmd
 }
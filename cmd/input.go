@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// newLineSource returns a source over args that reads "-" from cmd's input
+// and reports unreadable files on cmd's error output, prefixed with the
+// command name.
+func newLineSource(cmd *cobra.Command, args []string) (*utils.LineSource, error) {
+	src, err := utils.NewLineSource(args)
+	if err != nil {
+		return nil, err
+	}
+	setupLineSource(cmd, src)
+	return src, nil
+}
+
+// setupLineSource makes src read "-" from cmd's input, decode its files as
+// cmd's flags say and report unreadable files on cmd's error output.
+func setupLineSource(cmd *cobra.Command, src *utils.LineSource) {
+	src.Stdin = cmd.InOrStdin()
+	src.Decompress = decompressInput(cmd)
+	src.KeepCR, _ = cmd.Flags().GetBool("keep-cr")
+	src.ZeroTerminated, _ = cmd.Flags().GetBool("zero-terminated")
+	watchInput(cmd, src)
+	src.OnError = func(name string, err error) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", cmd.Name(), err)
+	}
+}
+
+// fileError deals with err from processing the current file of src. A
+// failed write to the output ends the command, so it is returned; any other
+// error is reported as a problem with the file, and the command goes on.
+func fileError(src *utils.LineSource, err error) error {
+	var oe *outputError
+	if errors.As(err, &oe) {
+		return err
+	}
+	if err != nil {
+		src.Fail(src.Name(), err)
+	}
+	return nil
+}
+
+// sourceStatus returns the error a command exits with once it has read
+// everything from src: a status of 1 if any file could not be read. Those
+// files have already been reported through src.OnError.
+func sourceStatus(src *utils.LineSource) error {
+	if src.Failed() {
+		return exitCode(1)
+	}
+	return nil
+}
+
+// openSources expands the globs in args and opens every file they name in
+// a source of its own, for commands that read their files side by side
+// rather than one after another. The sources are set up like those of
+// newLineSource, so their line endings are followed by --eol auto and
+// Reader returns each file decoded. Each file that cannot be opened is
+// reported on cmd's error output; if any could not, the others are closed
+// and the command exits with status.
+func openSources(cmd *cobra.Command, args []string, status int) ([]*utils.LineSource, error) {
+	names, err := utils.ExpandGlobs(args)
+	if err != nil {
+		return nil, exitWith(status, err)
+	}
+
+	srcs := make([]*utils.LineSource, 0, len(names))
+	failed := false
+	for _, name := range names {
+		src := utils.NewLineSourceFiles([]string{name})
+		setupLineSource(cmd, src)
+		if !src.NextFile() {
+			failed = true
+			continue
+		}
+		srcs = append(srcs, src)
+	}
+	if failed {
+		closeSources(srcs)
+		return nil, exitCode(status)
+	}
+	return srcs, nil
+}
+
+// closeSources closes the sources opened by openSources.
+func closeSources(srcs []*utils.LineSource) {
+	for _, src := range srcs {
+		src.Close()
+	}
+}
+
+// decompressInput reports whether cmd should decompress its input files:
+// when --decompress is given or cmd was called through its z alias, as in
+// zcat or zgrep.
+func decompressInput(cmd *cobra.Command) bool {
+	decompress, _ := cmd.Flags().GetBool("decompress")
+	return decompress || cmd.CalledAs() == "z"+cmd.Name()
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Ways --install can create the command names.
+const (
+	installSymlink  = "symlink"
+	installHardlink = "hardlink"
+	installCopy     = "copy"
+)
+
+// multiCallName returns the subcommand to run when bashutils is started
+// under another name, such as through a "grep" symlink, or "" if argv0
+// names bashutils itself or no command.
+func multiCallName(root *cobra.Command, argv0 string) string {
+	name := filepath.Base(argv0)
+	if ext := filepath.Ext(name); strings.EqualFold(ext, ".exe") {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if name == root.Name() {
+		return ""
+	}
+	for _, applet := range applets(root) {
+		if applet == name {
+			return name
+		}
+	}
+	return ""
+}
+
+// applets returns the names a bashutils command can be installed under:
+// every subcommand and its aliases, leaving out the help and completion
+// commands cobra adds.
+func applets(root *cobra.Command) []string {
+	var names []string
+	for _, c := range root.Commands() {
+		if c.Name() == "help" || c.Name() == "completion" || !c.IsAvailableCommand() {
+			continue
+		}
+		names = append(names, c.Name())
+		names = append(names, c.Aliases...)
+	}
+	return names
+}
+
+// installApplets creates one entry per applet in dir pointing at the
+// running executable. Entries that already exist are left alone unless
+// force is set. Every failure is reported, and the first one is returned
+// once all applets have been tried.
+func installApplets(cmd *cobra.Command, dir, mode string, force bool) error {
+	switch mode {
+	case installSymlink, installHardlink, installCopy:
+	default:
+		return usageError(cmd, fmt.Errorf("invalid install mode %q (want %s, %s or %s)",
+			mode, installSymlink, installHardlink, installCopy))
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the bashutils executable: %v", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("cannot locate the bashutils executable: %v", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	failed := false
+	for _, name := range applets(cmd.Root()) {
+		if runtime.GOOS == "windows" {
+			name += ".exe"
+		}
+		target := filepath.Join(dir, name)
+
+		if err := installApplet(exe, target, mode, force); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", cmd.Name(), err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n", target, exe)
+	}
+
+	if failed {
+		return exitCode(1)
+	}
+	return nil
+}
+
+func installApplet(exe, target, mode string, force bool) error {
+	if _, err := os.Lstat(target); err == nil {
+		if !force {
+			return fmt.Errorf("%s already exists (use --force to replace it)", target)
+		}
+		if same, _ := sameFile(exe, target); same {
+			// Already this executable; removing it could remove exe itself.
+			return nil
+		}
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	switch mode {
+	case installSymlink:
+		return os.Symlink(exe, target)
+	case installHardlink:
+		return os.Link(exe, target)
+	default:
+		return copyExecutable(exe, target)
+	}
+}
+
+// sameFile reports whether b is the file a, without following b if it is a
+// symlink.
+func sameFile(a, b string) (bool, error) {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	fb, err := os.Lstat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(fa, fb), nil
+}
+
+func copyExecutable(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/monster0506/bashutils-go/pkg/coreutils"
+	"github.com/spf13/cobra"
+)
+
+// localeFromEnv is the --locale value that takes the locale from the
+// environment, as --locale without a value does.
+const localeFromEnv = "auto"
+
+// addLocaleFlag adds the --locale flag for commands that can compare text
+// by Unicode collation.
+func addLocaleFlag(cmd *cobra.Command) {
+	cmd.Flags().String("locale", "", "compare text by Unicode collation for `LOCALE` (e.g. de, sv_SE.UTF-8; alone: from LC_ALL, LC_COLLATE or LANG)")
+	cmd.Flags().Lookup("locale").NoOptDefVal = localeFromEnv
+}
+
+// collationLocale returns the locale named by cmd's --locale flag, looking
+// it up in the environment for "auto". Without the flag it is "", which
+// compares bytes.
+func collationLocale(cmd *cobra.Command) (string, error) {
+	locale, _ := cmd.Flags().GetString("locale")
+	if locale == localeFromEnv {
+		locale = ""
+		for _, name := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+			if locale = os.Getenv(name); locale != "" {
+				break
+			}
+		}
+	}
+	if err := coreutils.CheckLocale(locale); err != nil {
+		return "", usageError(cmd, err)
+	}
+	return locale, nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// run runs a bashutils command line in-process, as pipe does, with stdin
+// as its standard input, and returns its output and exit status.
+func run(t *testing.T, stdin string, args ...string) (stdout, stderr string, status int) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	root := newRootCmd()
+	root.SetArgs(args)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetOut(&out)
+	root.SetErr(&errOut)
+	status = execute(root)
+	return out.String(), errOut.String(), status
+}
+
+// longLine is a line of several MB, far more than the 64 KiB a line reader
+// buffers, ending in a distinct word so truncation shows.
+var longLine = strings.Repeat("abcdefgh", 5<<20/8) + "end"
+
+func TestLongLine(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other.txt")
+	if err := os.WriteFile(other, []byte("other\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	field := strings.Repeat("y", 3<<20)
+
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+		want  string
+	}{
+		{"cat", longLine + "\nb\n", []string{"cat"}, longLine + "\nb\n"},
+		{"head", longLine + "\nb\n", []string{"head", "-n", "1"}, longLine + "\n"},
+		{"tail", "a\n" + longLine + "\n", []string{"tail", "-n", "1"}, longLine + "\n"},
+		{"grep", "a\n" + longLine + "\nb\n", []string{"grep", "hend"}, longLine + "\n"},
+		{"grep -c", longLine + "\n" + longLine + "\n", []string{"grep", "-c", "end$"}, "2\n"},
+		{"cut -f", "a," + field + ",b\n", []string{"cut", "-d", ",", "-f", "2"}, field + "\n"},
+		{"cut -c", longLine + "\n", []string{"cut", "-c", "1-3"}, "abc\n"},
+		{"wc", longLine + "\n", []string{"wc", "-l", "-c"}, ""},
+		{"paste", longLine + "\n", []string{"paste", "-", other}, longLine + "\tother\n"},
+		{"sort", longLine + "z\n" + longLine + "\n", []string{"sort"}, longLine + "\n" + longLine + "z\n"},
+		{"uniq", longLine + "\n" + longLine + "\nb\n", []string{"uniq"}, longLine + "\nb\n"},
+	}
+	for _, tt := range tests {
+		got, stderr, status := run(t, tt.stdin, tt.args...)
+		if status != 0 {
+			t.Errorf("%s: exit status %d: %s", tt.name, status, stderr)
+			continue
+		}
+		if tt.name == "wc" {
+			if f := strings.Fields(got); len(f) < 2 || f[0] != "1" || f[1] != "5242884" {
+				t.Errorf("wc: got %q, want 1 line and 5242884 bytes", got)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %d bytes %.40q, want %d bytes %.40q", tt.name, len(got), got, len(tt.want), tt.want)
+		}
+	}
+}
+
+func TestSplitLongLine(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if _, stderr, status := run(t, "a\n"+longLine+"\nb\n", "split", "-l", "1", "-"); status != 0 {
+		t.Fatalf("exit status %d: %s", status, stderr)
+	}
+	for name, want := range map[string]string{"xa": "a\n", "xb": longLine + "\n", "xc": "b\n"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s: got %d bytes, want %d", name, len(got), len(want))
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// statusBrokenPipe is the exit status of a command whose reader went away,
+// the one it would have if SIGPIPE had killed it.
+const statusBrokenPipe = 128 + 13
+
+// output buffers a command's standard output. Commands write to it a line
+// at a time, so writing straight to the file would cost a system call per
+// line. Output to a terminal is still written at once.
+type output struct {
+	bw        *bufio.Writer
+	autoFlush bool
+}
+
+// newOutput returns a buffered output writing to w. It must be flushed
+// once the command is done.
+func newOutput(w io.Writer) *output {
+	o := &output{bw: bufio.NewWriterSize(w, 64*1024)}
+	if f, ok := w.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+			o.autoFlush = true
+		}
+	}
+	return o
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	n, err := o.bw.Write(p)
+	if err == nil && o.autoFlush {
+		err = o.bw.Flush()
+	}
+	if err != nil {
+		return n, &outputError{err}
+	}
+	return n, nil
+}
+
+// Flush writes any buffered output.
+func (o *output) Flush() error {
+	if err := o.bw.Flush(); err != nil {
+		return &outputError{err}
+	}
+	return nil
+}
+
+// outputError is a failed write to a command's output. Commands stop at the
+// first one instead of going on with the next file.
+type outputError struct {
+	err error
+}
+
+func (e *outputError) Error() string {
+	return fmt.Sprintf("write error: %v", e.err)
+}
+
+func (e *outputError) Unwrap() error {
+	return e.err
+}
+
+// isBrokenPipe reports whether err is a write to a pipe whose reader has
+// gone away, as when the output is piped into head.
+func isBrokenPipe(err error) bool {
+	var oe *outputError
+	if !errors.As(err, &oe) {
+		return false
+	}
+	if errors.Is(oe.err, syscall.EPIPE) || errors.Is(oe.err, io.ErrClosedPipe) {
+		return true
+	}
+	// ERROR_BROKEN_PIPE and ERROR_NO_DATA ("The pipe is being closed").
+	var errno syscall.Errno
+	return runtime.GOOS == "windows" && errors.As(oe.err, &errno) && (errno == 109 || errno == 232)
+}
+
+// Values of the --eol flag.
+const (
+	eolAuto = "auto" // like the input
+	eolLF   = "lf"
+	eolCRLF = "crlf"
+)
+
+// configureOutput wraps cmd's output so that it ends lines as --eol asks.
+func configureOutput(cmd *cobra.Command) error {
+	eol, _ := cmd.Flags().GetString("eol")
+	switch eol {
+	case eolAuto, eolLF, eolCRLF:
+	default:
+		return usageError(cmd, fmt.Errorf("invalid --eol %q (want %s, %s or %s)", eol, eolAuto, eolLF, eolCRLF))
+	}
+	if eol != eolLF {
+		cmd.SetOut(&eolWriter{w: cmd.OutOrStdout(), mode: eol})
+	}
+	return nil
+}
+
+// eolWriter writes LF line endings as CRLF, either always or, in auto mode,
+// when the command's input uses CRLF. Input lines have their CR removed
+// when they are read, so this restores the original style.
+type eolWriter struct {
+	w    io.Writer
+	mode string
+	srcs []*utils.LineSource // the inputs consulted in auto mode
+	cr   bool                // the last byte written was a CR
+}
+
+// crlf reports whether LF is written as CRLF. In auto mode, the first
+// input that has shown its line ending decides, so commands that read
+// several files side by side follow the first file too.
+func (e *eolWriter) crlf() bool {
+	if e.mode == eolCRLF {
+		return true
+	}
+	for _, src := range e.srcs {
+		if ending := src.LineEnding(); ending != utils.LineEndingUnknown {
+			return ending == utils.LineEndingCRLF
+		}
+	}
+	return false
+}
+
+func (e *eolWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 || !e.crlf() {
+		if len(p) > 0 {
+			e.cr = p[len(p)-1] == '\r'
+		}
+		return e.w.Write(p)
+	}
+
+	out := make([]byte, 0, len(p)+len(p)/32)
+	for _, c := range p {
+		if c == '\n' && !e.cr {
+			out = append(out, '\r')
+		}
+		out = append(out, c)
+		e.cr = c == '\r'
+	}
+	if _, err := e.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// redirectOutput makes cmd write to w instead of its standard output,
+// keeping any --eol conversion.
+func redirectOutput(cmd *cobra.Command, w io.Writer) {
+	if e, ok := cmd.OutOrStdout().(*eolWriter); ok {
+		e.w = w
+		return
+	}
+	cmd.SetOut(w)
+}
+
+// watchInput lets an auto mode eolWriter on cmd's output follow src, after
+// any sources it already follows.
+func watchInput(cmd *cobra.Command, src *utils.LineSource) {
+	if e, ok := cmd.OutOrStdout().(*eolWriter); ok {
+		e.srcs = append(e.srcs, src)
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchInput is 200,000 short lines in no particular order.
+var benchInput = func() string {
+	var b strings.Builder
+	for i := 0; i < 200000; i++ {
+		fmt.Fprintf(&b, "line %d of the benchmark input %x\n", i, i*7919%200000)
+	}
+	return b.String()
+}()
+
+// benchmarkOutput runs the command line over benchInput with its output
+// going to a file, through the buffered output execute sets up
+// or, unbuffered, with a system call per write as for a terminal.
+func benchmarkOutput(b *testing.B, buffered bool, args ...string) {
+	f, err := os.Create(filepath.Join(b.TempDir(), "out"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	b.SetBytes(int64(len(benchInput)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			b.Fatal(err)
+		}
+		var w io.Writer = f
+		if !buffered {
+			w = &output{bw: bufio.NewWriterSize(f, 64*1024), autoFlush: true}
+		}
+		root := newRootCmd()
+		root.SetArgs(args)
+		root.SetIn(strings.NewReader(benchInput))
+		root.SetOut(w)
+		root.SetErr(io.Discard)
+		if status := execute(root); status != 0 {
+			b.Fatalf("exit status %d", status)
+		}
+	}
+}
+
+func BenchmarkCatBuffered(b *testing.B)    { benchmarkOutput(b, true, "cat") }
+func BenchmarkCatUnbuffered(b *testing.B)  { benchmarkOutput(b, false, "cat") }
+func BenchmarkGrepBuffered(b *testing.B)   { benchmarkOutput(b, true, "grep", "input") }
+func BenchmarkGrepUnbuffered(b *testing.B) { benchmarkOutput(b, false, "grep", "input") }
+func BenchmarkSortBuffered(b *testing.B)   { benchmarkOutput(b, true, "sort") }
+func BenchmarkSortUnbuffered(b *testing.B) { benchmarkOutput(b, false, "sort") }
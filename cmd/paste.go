@@ -1,92 +1,43 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"github.com/monster0506/bashutils-go/internal/utils"
-	"os"
-	"strings"
+	"io"
 
+	"github.com/monster0506/bashutils-go/pkg/coreutils"
 	"github.com/spf13/cobra"
 )
 
-var pasteCmd = &cobra.Command{
-	Use:   "paste [files...]",
-	Short: "Merge lines from files",
-	Args:  cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		delimitersStr, _ := cmd.Flags().GetString("delimiters")
-		serial, _ := cmd.Flags().GetBool("serial")
-
-		delimiters := []rune{'\t'} // Default delimiter
-		if delimitersStr != "" {
-			delimiters = []rune(delimitersStr)
-		}
-
-		if serial {
-			fmt.Fprintf(os.Stderr, "paste: --serial flag is not yet implemented.\n")
-			os.Exit(1)
-			return
-		}
-
-		// Expand glob patterns in file arguments
-		expandedArgs, err := utils.ExpandGlobsForReading(args)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "paste: %v\n", err)
-			return
-		}
+func newPasteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "paste [files...]",
+		Short: "Merge lines from files",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opts coreutils.PasteOptions
+			opts.Delimiters, _ = cmd.Flags().GetString("delimiters")
+			serial, _ := cmd.Flags().GetBool("serial")
+
+			if serial {
+				return fmt.Errorf("--serial flag is not yet implemented")
+			}
 
-		files := make([]*os.File, len(expandedArgs))
-		scanners := make([]*bufio.Scanner, len(expandedArgs))
-		for i, filePath := range expandedArgs {
-			file, err := os.Open(filePath)
+			srcs, err := openSources(cmd, args, 1)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "paste: %v\n", err)
-				return
+				return err
 			}
-			files[i] = file
-			scanners[i] = bufio.NewScanner(file)
-		}
-		defer func() {
-			for _, file := range files {
-				if file != nil {
-					file.Close()
-				}
+			defer closeSources(srcs)
+			readers := make([]io.Reader, len(srcs))
+			for i, src := range srcs {
+				readers[i] = src.Reader()
 			}
-		}()
 
-		var currentLines []string
-		moreData := true
-		for moreData {
-			currentLines = make([]string, len(scanners))
-			moreData = false
-			for i, scanner := range scanners {
-				if scanner.Scan() {
-					currentLines[i] = scanner.Text()
-					moreData = true
-				} else {
-					if err := scanner.Err(); err != nil {
-						fmt.Fprintf(os.Stderr, "paste: reading input: %v\n", err)
-						return
-					}
-					currentLines[i] = "" // Pad with empty string if file finished
-				}
-			}
-			if moreData {
-				var outputBuilder strings.Builder
-				for i, line := range currentLines {
-					outputBuilder.WriteString(line)
-					if i < len(currentLines)-1 {
-						outputBuilder.WriteRune(delimiters[i%len(delimiters)]) // Cycle through delimiters
-					}
-				}
-				fmt.Println(outputBuilder.String())
-			}
-		}
-	},
-}
+			return coreutils.Paste(cmd.Context(), readers, cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().StringP("delimiters", "d", "", "use specified delimiters instead of TAB")
+	cmd.Flags().BoolP("serial", "s", false, "paste one file at a time instead of in parallel (not yet implemented)")
 
-func init() {
-	pasteCmd.Flags().StringP("delimiters", "d", "", "use specified delimiters instead of TAB")
-	pasteCmd.Flags().BoolP("serial", "s", false, "paste one file at a time instead of in parallel (not yet implemented)")
+	return cmd
 }
@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+func newPipeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pipe 'command [| command...]'",
+		Short: "Run a pipeline of bashutils commands in-process",
+		Long: `pipe parses a shell-style pipeline and runs each stage as a bashutils
+command inside the current process, connecting the stages with in-memory
+pipes instead of starting a new process for every stage.
+
+Words may be quoted with single or double quotes. A backslash escapes a
+following space, quote, backslash or operator character and is otherwise kept,
+so Windows paths can be written as they are. Each stage may use the
+redirections <, >, >>, 2>, 2>> and 2>&1. A leading "bashutils" on a stage is
+ignored.
+
+The exit status is that of the rightmost stage that failed, or 0 if every
+stage succeeded (like bash with "set -o pipefail").`,
+		Example: `  bashutils pipe 'cat access.log | grep -i error | sort | uniq -c > errors.txt'
+  bashutils pipe 'sort -n < numbers.txt | head -n 3'`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pipestatus, _ := cmd.Flags().GetBool("pipestatus")
+
+			stages, err := parsePipeline(strings.Join(args, " "))
+			if err != nil {
+				return exitWith(2, err)
+			}
+
+			statuses := runPipeline(stages, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+
+			if pipestatus {
+				parts := make([]string, len(statuses))
+				for i, status := range statuses {
+					parts[i] = fmt.Sprint(status)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "PIPESTATUS: %s\n", strings.Join(parts, " "))
+			}
+
+			for i := len(statuses) - 1; i >= 0; i-- {
+				if statuses[i] != 0 {
+					return exitCode(statuses[i])
+				}
+			}
+			return nil
+		},
+	}
+
+	// Everything after the pipeline's first word belongs to the pipeline,
+	// not to pipe itself.
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().Bool("pipestatus", false, "print the exit status of every stage to standard error")
+
+	return cmd
+}
+
+// pipelineStage is one command of a pipeline together with its
+// redirections.
+type pipelineStage struct {
+	args           []string
+	stdin          string // file given with <
+	stdout         string // file given with > or >>
+	appendStdout   bool
+	stderr         string // file given with 2> or 2>>
+	appendStderr   bool
+	stderrToStdout bool // 2>&1
+}
+
+type pipelineToken struct {
+	text string
+	op   bool // an unquoted operator rather than a word
+}
+
+var pipelineOperators = []string{"2>&1", "2>>", "2>", ">>", ">", "<", "|"}
+
+// tokenizePipeline splits line into words and operators.
+func tokenizePipeline(line string) ([]pipelineToken, error) {
+	var tokens []pipelineToken
+	var word strings.Builder
+	inWord := false
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, pipelineToken{text: word.String()})
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush()
+			i++
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			inWord = true
+			i += end + 2
+		case c == '"':
+			i++
+			closed := false
+			for i < len(line) {
+				if line[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte(`"\$`+"`", line[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(line[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		case c == '\\':
+			if i+1 < len(line) && strings.IndexByte(" \t'\"\\|<>&", line[i+1]) >= 0 {
+				i++
+			}
+			word.WriteByte(line[i])
+			inWord = true
+			i++
+		default:
+			op := matchPipelineOperator(line[i:])
+			if strings.HasPrefix(op, "2") && inWord {
+				// In "x2>f" the 2 is part of the word x2.
+				op = ""
+			}
+			if op != "" {
+				flush()
+				tokens = append(tokens, pipelineToken{text: op, op: true})
+				i += len(op)
+				continue
+			}
+			word.WriteByte(c)
+			inWord = true
+			i++
+		}
+	}
+	flush()
+	return tokens, nil
+}
+
+func matchPipelineOperator(s string) string {
+	for _, op := range pipelineOperators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// parsePipeline parses a pipeline such as "cat a | grep x > out".
+func parsePipeline(line string) ([]*pipelineStage, error) {
+	tokens, err := tokenizePipeline(line)
+	if err != nil {
+		return nil, err
+	}
+
+	stage := &pipelineStage{}
+	stages := []*pipelineStage{stage}
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if !tok.op {
+			stage.args = append(stage.args, tok.text)
+			continue
+		}
+
+		switch tok.text {
+		case "|":
+			if len(stage.args) == 0 {
+				return nil, fmt.Errorf("syntax error near unexpected token `|'")
+			}
+			stage = &pipelineStage{}
+			stages = append(stages, stage)
+			continue
+		case "2>&1":
+			stage.stderrToStdout = true
+			continue
+		}
+
+		if i+1 >= len(tokens) || tokens[i+1].op {
+			return nil, fmt.Errorf("syntax error: missing file name after `%s'", tok.text)
+		}
+		i++
+		target := tokens[i].text
+		switch tok.text {
+		case "<":
+			stage.stdin = target
+		case ">", ">>":
+			stage.stdout, stage.appendStdout = target, tok.text == ">>"
+		case "2>", "2>>":
+			stage.stderr, stage.appendStderr = target, tok.text == "2>>"
+		}
+	}
+
+	if len(stage.args) == 0 {
+		if len(stages) == 1 {
+			return nil, fmt.Errorf("empty pipeline")
+		}
+		return nil, fmt.Errorf("syntax error: pipeline ends with `|'")
+	}
+	for _, st := range stages {
+		if st.args[0] == "bashutils" {
+			st.args = st.args[1:]
+		}
+		if len(st.args) == 0 {
+			return nil, fmt.Errorf("syntax error: missing command after bashutils")
+		}
+	}
+	return stages, nil
+}
+
+// runPipeline runs every stage concurrently, each reading the previous
+// stage's output, and returns their exit statuses.
+func runPipeline(stages []*pipelineStage, stdin io.Reader, stdout, stderr io.Writer) []int {
+	statuses := make([]int, len(stages))
+	var wg sync.WaitGroup
+
+	in := stdin
+	for i, st := range stages {
+		out := stdout
+		var next io.Reader
+		var pw *io.PipeWriter
+		if i < len(stages)-1 {
+			pr, w := io.Pipe()
+			next, out, pw = pr, w, w
+		}
+
+		wg.Add(1)
+		go func(i int, st *pipelineStage, in io.Reader, out io.Writer, pw *io.PipeWriter) {
+			defer wg.Done()
+			statuses[i] = runStage(st, in, out, stderr)
+			// Let the next stage see end of input, and make writes from the
+			// previous stage fail now that nobody is reading them.
+			if pw != nil {
+				pw.Close()
+			}
+			if pr, ok := in.(*io.PipeReader); ok {
+				pr.Close()
+			}
+		}(i, st, in, out, pw)
+
+		in = next
+	}
+
+	wg.Wait()
+	return statuses
+}
+
+// runStage applies st's redirections and runs its command on a fresh
+// command tree, returning the exit status.
+func runStage(st *pipelineStage, stdin io.Reader, stdout, stderr io.Writer) int {
+	if st.stdin != "" {
+		f, err := os.Open(st.stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "pipe: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		stdin = f
+	}
+	if st.stdout != "" {
+		f, err := openRedirect(st.stdout, st.appendStdout)
+		if err != nil {
+			fmt.Fprintf(stderr, "pipe: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		stdout = f
+	}
+	if st.stderr != "" {
+		f, err := openRedirect(st.stderr, st.appendStderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "pipe: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		stderr = f
+	}
+	if st.stderrToStdout {
+		// Share one buffer so that the two streams stay in order.
+		out := newOutput(stdout)
+		stdout, stderr = out, out
+	}
+
+	root := newRootCmd()
+	if c, _, err := root.Find(st.args); err != nil || c == root {
+		fmt.Fprintf(stderr, "pipe: %s: command not found\n", st.args[0])
+		return 127
+	}
+
+	root.SetArgs(st.args)
+	root.SetIn(stdin)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+	return execute(root)
+}
+
+func openRedirect(name string, appendTo bool) (*os.File, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendTo {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	return os.OpenFile(name, flags, 0o644)
+}
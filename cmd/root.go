@@ -1,36 +1,156 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/monster0506/bashutils-go/internal/utils"
 	"github.com/spf13/cobra"
 )
 
-var rootCmd = &cobra.Command{
-	Use:   "bashutils",
-	Short: "A Go-based reimplementation of bash coreutils",
-	Long:  `bashutils is a CLI tool written in Go that mimics common bash coreutils commands.`,
-	Args:  cobra.MinimumNArgs(1),
+// newRootCmd builds the bashutils command tree. Each call returns fresh
+// commands with their own flag state, so several trees can run at once.
+func newRootCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bashutils",
+		Short: "A Go-based reimplementation of bash coreutils",
+		Long: `bashutils is a CLI tool written in Go that mimics common bash coreutils commands.
+
+Glob expansion can be tuned with the --nullglob, --failglob, --dotglob,
+--nocaseglob and --globstar flags, or through the ` + utils.GlobOptionsEnv + `
+environment variable (e.g. ` + utils.GlobOptionsEnv + `=failglob:nocaseglob).
+Flags take precedence over the environment.
+
+bashutils is a multi-call binary: started through a link or copy named after
+one of its commands (grep, grep.exe, ...), it runs that command. Use
+--install DIR to create such links for every command.`,
+		Example: `  bashutils --install /usr/local/bin
+  bashutils --install C:\tools\bin --install-mode copy --force
+  bashutils --list`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := configureGlobOptions(cmd); err != nil {
+				return err
+			}
+			return configureOutput(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, _ := cmd.Flags().GetString("install")
+			list, _ := cmd.Flags().GetBool("list")
+
+			switch {
+			case list:
+				for _, name := range applets(cmd) {
+					fmt.Fprintln(cmd.OutOrStdout(), name)
+				}
+				return nil
+			case dir != "":
+				mode, _ := cmd.Flags().GetString("install-mode")
+				force, _ := cmd.Flags().GetBool("force")
+				return installApplets(cmd, dir, mode, force)
+			default:
+				return cmd.Help()
+			}
+		},
+	}
+
+	cmd.SetFlagErrorFunc(usageError)
+
+	cmd.PersistentFlags().Bool("nullglob", false, "patterns matching no files expand to nothing")
+	cmd.PersistentFlags().Bool("failglob", false, "patterns matching no files are an error")
+	cmd.PersistentFlags().Bool("dotglob", false, "wildcards also match files starting with '.'")
+	cmd.PersistentFlags().Bool("nocaseglob", false, "match glob patterns case-insensitively")
+	cmd.PersistentFlags().Bool("globstar", true, "let ** match any number of directories")
+	cmd.PersistentFlags().Bool("keep-cr", false, "keep the CR of CRLF line endings in input lines")
+	cmd.PersistentFlags().String("eol", eolAuto, "output line endings: auto (like the input), lf or crlf")
+	cmd.PersistentFlags().Bool("decompress", false, "read gzip, bzip2, zlib and compress (.Z) files as their content")
+
+	cmd.Flags().String("install", "", "create a link named after every command in `DIR`")
+	cmd.Flags().String("install-mode", installSymlink, "how --install creates the commands: symlink, hardlink or copy")
+	cmd.Flags().Bool("force", false, "let --install replace existing files")
+	cmd.Flags().Bool("list", false, "list the commands that --install creates")
+
+	cmd.AddCommand(newEchoCmd())
+	cmd.AddCommand(newCatCmd())
+	cmd.AddCommand(newHeadCmd())
+	cmd.AddCommand(newTailCmd())
+	cmd.AddCommand(newWcCmd())
+	cmd.AddCommand(newCutCmd())
+	cmd.AddCommand(newSortCmd())
+	cmd.AddCommand(newUniqCmd())
+	cmd.AddCommand(newGrepCmd())
+	cmd.AddCommand(newTrCmd())
+	cmd.AddCommand(newPasteCmd())
+	cmd.AddCommand(newSplitCmd())
+	cmd.AddCommand(newXargsCmd())
+	cmd.AddCommand(newPipeCmd())
+
+	return cmd
 }
 
+// Execute runs the command named on the command line and exits with its
+// status. When the executable was started under the name of one of its
+// commands, that command is run with all the arguments.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	// Have writes to a closed pipe fail with EPIPE, which commands handle,
+	// rather than kill the process before its output is flushed.
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
+
+	root := newRootCmd()
+	if name := multiCallName(root, os.Args[0]); name != "" {
+		root.SetArgs(append([]string{name}, os.Args[1:]...))
 	}
+	root.SetOut(os.Stdout)
+	os.Exit(execute(root))
 }
 
-func init() {
-	rootCmd.AddCommand(echoCmd)
-	rootCmd.AddCommand(catCmd)
-	rootCmd.AddCommand(headCmd)
-	rootCmd.AddCommand(tailCmd)
-	rootCmd.AddCommand(wcCmd)
-	rootCmd.AddCommand(cutCmd)
-	rootCmd.AddCommand(sortCmd)
-	rootCmd.AddCommand(uniqCmd)
-	rootCmd.AddCommand(grepCmd)
-	rootCmd.AddCommand(trCmd)
-	rootCmd.AddCommand(pasteCmd)
-	rootCmd.AddCommand(splitCmd)
-	rootCmd.AddCommand(xargsCmd)
+// execute runs root with its output buffered and returns the exit status.
+// Errors are printed to the failing command's error output, prefixed with
+// its name.
+func execute(root *cobra.Command) int {
+	out, ok := root.OutOrStdout().(*output)
+	if !ok {
+		out = newOutput(root.OutOrStdout())
+		root.SetOut(out)
+	}
+
+	cmd, err := root.ExecuteC()
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		if msg := errorMessage(err); msg != "" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", cmd.Name(), msg)
+		}
+	}
+	return exitStatus(err)
+}
+
+// configureGlobOptions sets the shared glob options from the environment
+// and then from any glob flags given on the command line.
+func configureGlobOptions(cmd *cobra.Command) error {
+	opts, err := utils.ParseGlobOptions(os.Getenv(utils.GlobOptionsEnv), utils.DefaultGlobOptions())
+	if err != nil {
+		return err
+	}
+
+	flags := map[string]*bool{
+		"nullglob":   &opts.NullGlob,
+		"failglob":   &opts.FailGlob,
+		"dotglob":    &opts.DotGlob,
+		"nocaseglob": &opts.NoCaseGlob,
+		"globstar":   &opts.GlobStar,
+	}
+	for name, field := range flags {
+		if cmd.Flags().Changed(name) {
+			*field, _ = cmd.Flags().GetBool(name)
+		}
+	}
+
+	utils.SetGlobOptions(opts)
+	return nil
 }
@@ -2,103 +2,306 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/monster0506/bashutils-go/internal/utils"
+	"io"
+	"math"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+	"github.com/monster0506/bashutils-go/pkg/coreutils"
 	"github.com/spf13/cobra"
 )
 
-var sortCmd = &cobra.Command{
-	Use:   "sort [files...]",
-	Short: "Sort lines of text files",
-	Args:  cobra.ArbitraryArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		reverse, _ := cmd.Flags().GetBool("reverse")
-		numeric, _ := cmd.Flags().GetBool("numeric-sort")
-		unique, _ := cmd.Flags().GetBool("unique")
-		column, _ := cmd.Flags().GetInt("key")
-		separator, _ := cmd.Flags().GetString("field-separator")
-
-		allLines, err := utils.ReadLinesFromFilesOrStdin(args)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "sort: %v\n", err)
-			return
-		}
+func newSortCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sort [files...]",
+		Short: "Sort lines of text files",
+		Long: `Sort lines of text files.
 
-		sort.Slice(allLines, func(i, j int) bool {
-			var keyI, keyJ string
-
-			if column > 0 {
-				var columnsI, columnsJ []string
-				if separator != "" {
-					columnsI = strings.Split(allLines[i], separator)
-					columnsJ = strings.Split(allLines[j], separator)
-				} else {
-					columnsI = strings.Fields(allLines[i])
-					columnsJ = strings.Fields(allLines[j])
-				}
+Each -k KEYDEF selects part of the line to compare, as POS1[,POS2] where
+a POS is F[.C][OPTS]: field F, character C of that field, and ordering
+options from bdfgiMhnRrV that apply to this key only. Without POS2 the key
+runs to the end of the line. Keys are compared in the order given; lines
+whose keys are all equal are compared as a whole, unless -s or -u is
+given.
 
-				if column <= len(columnsI) {
-					keyI = columnsI[column-1]
-				}
-				if column <= len(columnsJ) {
-					keyJ = columnsJ[column-1]
-				}
-			} else {
-				keyI = allLines[i]
-				keyJ = allLines[j]
-			}
+Input too large for the memory buffer set with -S is sorted in pieces that
+are written to temporary files under -T DIR and then merged.
 
-			if numeric {
-				numI, errI := strconv.ParseFloat(keyI, 64)
-				numJ, errJ := strconv.ParseFloat(keyJ, 64)
+With -c or -C, sort only checks that its input is already sorted and exits
+with status 1 if not; -c also reports the first line out of order. With
+-m, the files are already sorted and are merged line by line. Any other
+trouble, such as an unreadable file or an invalid option, makes sort exit
+with status 2.
 
-				if errI != nil && errJ != nil {
-					return keyI < keyJ
+-o FILE writes to a temporary file next to FILE and renames it over FILE
+once sorting is done, so FILE can also be one of the inputs.`,
+		Example: `  sort -t, -k3,3n -k1,1r data.csv
+  sort -k2.3b,2.5 -s log.txt
+  sort -c -t, -k2,2n data.csv
+  sort -m -k1,1 shard1.txt shard2.txt shard3.txt
+  sort -o names.txt names.txt`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opts coreutils.SortOptions
+			opts.Reverse, _ = cmd.Flags().GetBool("reverse")
+			opts.IgnoreBlanks, _ = cmd.Flags().GetBool("ignore-leading-blanks")
+			opts.FoldCase, _ = cmd.Flags().GetBool("ignore-case")
+			opts.Dictionary, _ = cmd.Flags().GetBool("dictionary-order")
+			opts.IgnoreNonprinting, _ = cmd.Flags().GetBool("ignore-nonprinting")
+			for _, order := range sortOrderFlags {
+				if set, _ := cmd.Flags().GetBool(order.name); set {
+					if err := opts.Set(order.letter); err != nil {
+						return exitWith(2, usageError(cmd, err))
+					}
 				}
-				if errI != nil {
-					return false
+			}
+			locale, err := collationLocale(cmd)
+			if err != nil {
+				return exitWith(2, err)
+			}
+			opts.Locale = locale
+			if source, _ := cmd.Flags().GetString("random-source"); source != "" {
+				seed, err := readRandomSource(source)
+				if err != nil {
+					return exitWith(2, err)
 				}
-				if errJ != nil {
-					return true
+				opts.RandomSeed = seed
+			}
+			opts.Unique, _ = cmd.Flags().GetBool("unique")
+			opts.Stable, _ = cmd.Flags().GetBool("stable")
+			sep, _ := cmd.Flags().GetString("field-separator")
+			switch {
+			case sep == `\0`:
+				sep = "\x00"
+			case sep == "" && cmd.Flags().Changed("field-separator"):
+				return exitWith(2, usageError(cmd, fmt.Errorf("empty tab")))
+			case utf8.RuneCountInString(sep) > 1:
+				return exitWith(2, usageError(cmd, fmt.Errorf("multi-character tab %q", sep)))
+			}
+			opts.FieldSeparator = sep
+			opts.ZeroTerminated, _ = cmd.Flags().GetBool("zero-terminated")
+			opts.TempDir, _ = cmd.Flags().GetString("temporary-directory")
+			opts.Parallel, _ = cmd.Flags().GetInt("parallel")
+			if opts.Parallel < 0 {
+				return exitWith(2, usageError(cmd, fmt.Errorf("invalid number of parallel sorts: %d", opts.Parallel)))
+			}
+			if size, _ := cmd.Flags().GetString("buffer-size"); size != "" {
+				n, err := parseBufferSize(size)
+				if err != nil {
+					return exitWith(2, usageError(cmd, err))
 				}
-				return numI < numJ
+				opts.BufferSize = n
 			}
 
-			return keyI < keyJ
-		})
+			specs, _ := cmd.Flags().GetStringArray("key")
+			for _, spec := range specs {
+				key, err := coreutils.ParseSortKey(spec)
+				if err != nil {
+					return exitWith(2, usageError(cmd, err))
+				}
+				opts.Keys = append(opts.Keys, key)
+			}
 
-		if reverse {
-			for i, j := 0, len(allLines)-1; i < j; i, j = i+1, j-1 {
-				allLines[i], allLines[j] = allLines[j], allLines[i]
+			check, _ := cmd.Flags().GetBool("check")
+			quiet, _ := cmd.Flags().GetBool("check-silent")
+			merge, _ := cmd.Flags().GetBool("merge")
+			output, _ := cmd.Flags().GetString("output")
+			switch {
+			case check && quiet:
+				return exitWith(2, usageError(cmd, fmt.Errorf("options '-cC' are incompatible")))
+			case (check || quiet) && merge:
+				return exitWith(2, usageError(cmd, fmt.Errorf("options '-cm' are incompatible")))
+			case (check || quiet) && output != "":
+				return exitWith(2, usageError(cmd, fmt.Errorf("options '-co' are incompatible")))
+			case check || quiet:
+				if len(args) > 1 {
+					return exitWith(2, usageError(cmd, fmt.Errorf("extra operand %q not allowed with -c", args[1])))
+				}
+				return checkSorted(cmd, args, opts, quiet)
 			}
-		}
 
-		if unique {
-			var uniqueLines []string
-			if len(allLines) > 0 {
-				uniqueLines = append(uniqueLines, allLines[0])
-				for i := 1; i < len(allLines); i++ {
-					if allLines[i] != allLines[i-1] {
-						uniqueLines = append(uniqueLines, allLines[i])
-					}
+			// The output file replaces its target only once everything
+			// has been read, so it may be one of the inputs.
+			var out *utils.AtomicFile
+			if output != "" {
+				if out, err = utils.CreateAtomic(output); err != nil {
+					return exitWith(2, err)
 				}
+				defer out.Abort()
+				redirectOutput(cmd, out)
 			}
-			allLines = uniqueLines
-		}
 
-		for _, line := range allLines {
-			fmt.Println(line)
+			if merge {
+				err = mergeSorted(cmd, args, opts)
+			} else {
+				err = sortFiles(cmd, args, opts)
+			}
+			if err == nil && out != nil {
+				if err := out.Commit(); err != nil {
+					return exitWith(2, err)
+				}
+			}
+			return err
+		},
+	}
+
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return exitWith(2, usageError(cmd, err))
+	})
+
+	cmd.Flags().BoolP("reverse", "r", false, "sort in reverse order")
+	cmd.Flags().BoolP("ignore-leading-blanks", "b", false, "ignore leading blanks of fields")
+	cmd.Flags().BoolP("ignore-case", "f", false, "fold lower case to upper case characters")
+	cmd.Flags().BoolP("dictionary-order", "d", false, "consider only blanks and alphanumeric characters")
+	cmd.Flags().BoolP("ignore-nonprinting", "i", false, "consider only printable characters")
+	addLocaleFlag(cmd)
+	cmd.Flags().BoolP("numeric-sort", "n", false, "compare according to string numerical value")
+	cmd.Flags().BoolP("general-numeric-sort", "g", false, "compare according to general numerical value, as 64-bit floats")
+	cmd.Flags().BoolP("human-numeric-sort", "h", false, "compare human readable numbers (e.g., 2K 1G)")
+	cmd.Flags().BoolP("month-sort", "M", false, "compare (unknown) < 'JAN' < ... < 'DEC'")
+	cmd.Flags().BoolP("version-sort", "V", false, "natural sort of (version) numbers within text")
+	cmd.Flags().BoolP("random-sort", "R", false, "shuffle, but group identical keys")
+	cmd.Flags().String("random-source", "", "get random bytes from `FILE`")
+	// -h is --human-numeric-sort, so --help goes without a shorthand.
+	cmd.Flags().Bool("help", false, "help for sort")
+	cmd.Flags().BoolP("unique", "u", false, "output only the first of an equal run")
+	cmd.Flags().BoolP("stable", "s", false, "keep lines with equal keys in input order")
+	cmd.Flags().StringArrayP("key", "k", nil, "sort via a key; `KEYDEF` gives location and type")
+	cmd.Flags().StringP("field-separator", "t", "", "use `SEP`, one character or \\0 for NUL, as field separator")
+	cmd.Flags().BoolP("zero-terminated", "z", false, "line delimiter is NUL, not newline")
+	cmd.Flags().StringP("buffer-size", "S", "", "use `SIZE` for the main memory buffer (e.g. 512M, default unit K)")
+	cmd.Flags().StringP("temporary-directory", "T", "", "use `DIR` for temporary files, not $TMPDIR or /tmp")
+	cmd.Flags().StringP("output", "o", "", "write result to `FILE` instead of standard output")
+	cmd.Flags().BoolP("check", "c", false, "check for sorted input; report the first disorder")
+	cmd.Flags().BoolP("check-silent", "C", false, "like -c, but do not report the first disorder")
+	cmd.Flags().BoolP("merge", "m", false, "merge already sorted files; do not sort")
+	cmd.Flags().Int("parallel", 0, "sort with `N` goroutines at once (default: number of CPUs, up to 8)")
+
+	return cmd
+}
+
+// sortOrderFlags are the flags that choose how sort compares keys, with
+// the key modifier letter each one stands for.
+var sortOrderFlags = []struct {
+	name   string
+	letter rune
+}{
+	{"numeric-sort", 'n'},
+	{"general-numeric-sort", 'g'},
+	{"human-numeric-sort", 'h'},
+	{"month-sort", 'M'},
+	{"version-sort", 'V'},
+	{"random-sort", 'R'},
+}
+
+// randomSourceSize is how many bytes of --random-source seed a random sort.
+const randomSourceSize = 32
+
+// readRandomSource reads the seed for a random sort from the named file.
+func readRandomSource(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	seed := make([]byte, randomSourceSize)
+	n, err := io.ReadFull(f, seed)
+	if n == 0 {
+		if err == io.EOF {
+			err = fmt.Errorf("%s: end of file", name)
 		}
-	},
+		return nil, err
+	}
+	return seed[:n], nil
 }
 
-func init() {
-	sortCmd.Flags().BoolP("reverse", "r", false, "sort in reverse order")
-	sortCmd.Flags().BoolP("numeric-sort", "n", false, "compare according to string numerical value")
-	sortCmd.Flags().BoolP("unique", "u", false, "output only the first of an equal run")
-	sortCmd.Flags().IntP("key", "k", 0, "sort by the specified column (1-based index)")
-	sortCmd.Flags().StringP("field-separator", "t", "", "use specified character as field separator")
-}
\ No newline at end of file
+// sortFiles sorts the lines of the files named by args.
+func sortFiles(cmd *cobra.Command, args []string, opts coreutils.SortOptions) error {
+	src, err := newLineSource(cmd, args)
+	if err != nil {
+		return exitWith(2, err)
+	}
+	defer src.Close()
+
+	if err := coreutils.Sort(cmd.Context(), src.Concat(), cmd.OutOrStdout(), opts); err != nil {
+		return exitWith(2, err)
+	}
+	if src.Failed() {
+		return exitCode(2)
+	}
+	return nil
+}
+
+// checkSorted exits with status 1 if the input is not sorted according to
+// opts, reporting the first line out of order unless quiet, and with
+// status 2 if it cannot be read.
+func checkSorted(cmd *cobra.Command, args []string, opts coreutils.SortOptions, quiet bool) error {
+	src, err := newLineSource(cmd, args)
+	if err != nil {
+		return exitWith(2, err)
+	}
+	defer src.Close()
+
+	disorder, err := coreutils.CheckSorted(cmd.Context(), src.Concat(), opts)
+	if err != nil {
+		return exitWith(2, err)
+	}
+	if src.Failed() {
+		return exitCode(2)
+	}
+	switch {
+	case disorder == nil:
+		return nil
+	case quiet:
+		return exitCode(1)
+	default:
+		return exitWith(1, fmt.Errorf("%s:%d: disorder: %s", src.Name(), disorder.Line, disorder.Text))
+	}
+}
+
+// mergeSorted merges the files named by args, which are already sorted,
+// without sorting them again.
+func mergeSorted(cmd *cobra.Command, args []string, opts coreutils.SortOptions) error {
+	if len(args) == 0 {
+		args = []string{utils.StdinName}
+	}
+	srcs, err := openSources(cmd, args, 2)
+	if err != nil {
+		return err
+	}
+	defer closeSources(srcs)
+
+	readers := make([]io.Reader, len(srcs))
+	for i, src := range srcs {
+		readers[i] = src.Reader()
+	}
+
+	if err := coreutils.Merge(cmd.Context(), readers, cmd.OutOrStdout(), opts); err != nil {
+		return exitWith(2, err)
+	}
+	return nil
+}
+
+// parseBufferSize parses the SIZE of sort -S: a number of kibibytes, or a
+// number followed by b for bytes or one of K, M, G, T, P and E.
+func parseBufferSize(s string) (int64, error) {
+	digits := strings.TrimRight(s, "bBkKmMgGtTpPeE")
+	suffix := strings.ToUpper(s[len(digits):])
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || n < 0 || len(suffix) > 1 {
+		return 0, fmt.Errorf("invalid buffer size: %q", s)
+	}
+
+	shift := 10
+	if suffix != "" {
+		shift = 10 * strings.Index("BKMGTPE", suffix)
+	}
+	if n > math.MaxInt64>>shift {
+		return math.MaxInt64, nil
+	}
+	return n << shift, nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSortExitStatus(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+		want  int
+	}{
+		{"sorted", "b\na\n", []string{"sort"}, 0},
+		{"check sorted", "a\nb\n", []string{"sort", "-c"}, 0},
+		{"check disorder", "b\na\n", []string{"sort", "-c"}, 1},
+		{"quiet check disorder", "b\na\n", []string{"sort", "-C"}, 1},
+		{"missing file", "", []string{"sort", missing}, 2},
+		{"missing file and stdin", "a\n", []string{"sort", "-", missing}, 2},
+		{"check missing file", "", []string{"sort", "-c", missing}, 2},
+		{"quiet check missing file", "", []string{"sort", "-C", missing}, 2},
+		{"merge missing file", "", []string{"sort", "-m", missing}, 2},
+		{"invalid key", "", []string{"sort", "-k", "0"}, 2},
+		{"incompatible orders", "", []string{"sort", "-n", "-g"}, 2},
+		{"incompatible checks", "", []string{"sort", "-c", "-C"}, 2},
+		{"extra operand with check", "", []string{"sort", "-c", "a", "b"}, 2},
+		{"invalid buffer size", "", []string{"sort", "-S", "x"}, 2},
+		{"multi-character tab", "", []string{"sort", "-t", "ab"}, 2},
+		{"empty tab", "", []string{"sort", "-t", ""}, 2},
+		{"NUL tab", "a\x00b\n", []string{"sort", "-t", `\0`, "-k2"}, 0},
+		{"non-ASCII tab", "aéb\n", []string{"sort", "-t", "é", "-k2"}, 0},
+		{"unknown flag", "", []string{"sort", "--no-such-flag"}, 2},
+		{"missing random source", "", []string{"sort", "-R", "--random-source", missing}, 2},
+	}
+	for _, tt := range tests {
+		_, stderr, status := run(t, tt.stdin, tt.args...)
+		if status != tt.want {
+			t.Errorf("%s: exit status %d, want %d (%s)", tt.name, status, tt.want, stderr)
+		}
+		if tt.want == 2 && stderr == "" {
+			t.Errorf("%s: no error message", tt.name)
+		}
+	}
+}
+
+func TestSortMergeEOL(t *testing.T) {
+	p := writeFiles(t, "a.crlf", "a\r\nc\r\n", "b.crlf", "b\r\nd\r\n", "x.lf", "x\ny\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"CRLF files", []string{"sort", "-m", p[0], p[1]}, "a\r\nb\r\nc\r\nd\r\n"},
+		{"same as sort", []string{"sort", p[0], p[1]}, "a\r\nb\r\nc\r\nd\r\n"},
+		{"first file decides", []string{"sort", "-m", p[2], p[0]}, "a\nc\nx\ny\n"},
+		{"eol lf", []string{"sort", "-m", "--eol", "lf", p[0], p[1]}, "a\nb\nc\nd\n"},
+	}
+	for _, tt := range tests {
+		got, stderr, status := run(t, "", tt.args...)
+		if status != 0 {
+			t.Errorf("%s: exit status %d: %s", tt.name, status, stderr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
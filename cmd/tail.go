@@ -1,72 +1,52 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"github.com/monster0506/bashutils-go/internal/utils"
+
+	"github.com/monster0506/bashutils-go/pkg/coreutils"
 	"github.com/spf13/cobra"
-	"os"
 )
 
-var tailCmd = &cobra.Command{
-	Use:   "tail [files...]",
-	Short: "Output the last part of files",
-	Args:  cobra.ArbitraryArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		n, _ := cmd.Flags().GetInt("lines")
-		
-		if len(args) == 0 {
-			// Read from stdin when no files provided
-			scanner := bufio.NewScanner(os.Stdin)
-			lines := []string{}
-			for scanner.Scan() {
-				lines = append(lines, scanner.Text())
-				if len(lines) > n {
-					lines = lines[1:]
-				}
-			}
-			for _, line := range lines {
-				fmt.Println(line)
-			}
-		} else {
-			// Expand glob patterns in arguments
-			expandedArgs, err := utils.ExpandGlobsForReading(args)
+func newTailCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tail [files...]",
+		Short: "Output the last part of files",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+
+			var opts coreutils.TailOptions
+			opts.Lines, _ = cmd.Flags().GetInt("lines")
+			opts.ZeroTerminated, _ = cmd.Flags().GetBool("zero-terminated")
+
+			src, err := newLineSource(cmd, args)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "tail: %v\n", err)
-				return
+				return err
 			}
-			
-			for _, path := range expandedArgs {
-				if len(expandedArgs) > 1 {
-					fmt.Printf("==> %s <==\n", path)
-				}
-				
-				f, err := os.Open(path)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "tail: %v\n", err)
-					continue
-				}
-				defer f.Close()
-				scanner := bufio.NewScanner(f)
-				lines := []string{}
-				for scanner.Scan() {
-					lines = append(lines, scanner.Text())
-					if len(lines) > n {
-						lines = lines[1:]
+			defer src.Close()
+
+			first := true
+			for src.NextFile() {
+				if src.NumFiles() > 1 {
+					if !first {
+						fmt.Fprintln(out)
 					}
+					fmt.Fprintf(out, "==> %s <==\n", src.Name())
 				}
-				for _, line := range lines {
-					fmt.Println(line)
-				}
-				
-				if len(expandedArgs) > 1 && path != expandedArgs[len(expandedArgs)-1] {
-					fmt.Println()
+				first = false
+
+				err := coreutils.Tail(cmd.Context(), src.Reader(), out, opts)
+				if err := fileError(src, err); err != nil {
+					return err
 				}
 			}
-		}
-	},
-}
 
-func init() {
-	tailCmd.Flags().IntP("lines", "n", 10, "number of lines to show from end")
+			return sourceStatus(src)
+		},
+	}
+
+	cmd.Flags().IntP("lines", "n", 10, "number of lines to show from end")
+	cmd.Flags().BoolP("zero-terminated", "z", false, "line delimiter is NUL, not newline")
+
+	return cmd
 }
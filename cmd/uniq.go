@@ -2,101 +2,129 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/monster0506/bashutils-go/internal/utils"
-	"os"
-	"sort"
 
+	"github.com/monster0506/bashutils-go/pkg/coreutils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-var uniqCmd = &cobra.Command{
-	Use:   "uniq [files...]",
-	Short: "Filter out repeated lines",
-	Args:  cobra.ArbitraryArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		count, _ := cmd.Flags().GetBool("count")
-		repeated, _ := cmd.Flags().GetBool("repeated")
-		unique, _ := cmd.Flags().GetBool("unique")
+func newUniqCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "uniq [files...]",
+		Short: "Filter out repeated lines",
+		Long: `Filter out repeated lines.
 
-		allLines, err := utils.ReadLinesFromFilesOrStdin(args)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "uniq: %v\n", err)
-			return
-		}
-
-		// uniq typically operates on sorted input.
-		// For simplicity, we'll sort here if the input isn't guaranteed to be.
-		// A more "unix-like" implementation would expect sorted input from a pipe.
-		sort.Strings(allLines)
-
-		if len(allLines) == 0 {
-			return
-		}
-
-		// First pass: collect all counts to determine column width
-		var allCounts []struct {
-			line  string
-			count int
-		}
-		var maxCountWidth int
+Like POSIX uniq, only adjacent equal lines are collapsed, so the input is
+usually sorted first; lines are read one at a time. With --global (or
+--all), equal lines are collapsed wherever they occur and printed in the
+order they first appear, which holds every distinct line in memory.
 
-		currentLine := allLines[0]
-		currentCount := 1
-		for i := 1; i < len(allLines); i++ {
-			if allLines[i] == currentLine {
-				currentCount++
-			} else {
-				if shouldPrintLine(currentCount, repeated, unique) {
-					allCounts = append(allCounts, struct {
-						line  string
-						count int
-					}{currentLine, currentCount})
-					
-					countStr := fmt.Sprintf("%d", currentCount)
-					if len(countStr) > maxCountWidth {
-						maxCountWidth = len(countStr)
-					}
+-f, -s and -w limit the comparison to part of each line, for instance to
+skip a timestamp. -D prints all lines of every repeated group, or all but
+the last with -u, and --group prints all lines with the groups set apart by
+empty lines; where the empty lines go is chosen by METHOD.`,
+		Example: `  sort access.log | uniq -c
+  uniq --global names.txt
+  uniq -f 2 -c app.log
+  uniq -D=separate -w 8 ids.txt`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opts coreutils.UniqOptions
+			opts.Count, _ = cmd.Flags().GetBool("count")
+			opts.Repeated, _ = cmd.Flags().GetBool("repeated")
+			opts.Unique, _ = cmd.Flags().GetBool("unique")
+			opts.Global, _ = cmd.Flags().GetBool("global")
+			opts.ZeroTerminated, _ = cmd.Flags().GetBool("zero-terminated")
+			opts.IgnoreCase, _ = cmd.Flags().GetBool("ignore-case")
+			opts.SkipFields, _ = cmd.Flags().GetInt("skip-fields")
+			opts.SkipChars, _ = cmd.Flags().GetInt("skip-chars")
+			opts.CheckChars, _ = cmd.Flags().GetInt("check-chars")
+			for _, name := range []string{"skip-fields", "skip-chars", "check-chars"} {
+				if n, _ := cmd.Flags().GetInt(name); n < 0 {
+					return usageError(cmd, fmt.Errorf("invalid --%s: %d", name, n))
 				}
-				currentLine = allLines[i]
-				currentCount = 1
 			}
-		}
-		// Don't forget the last group
-		if shouldPrintLine(currentCount, repeated, unique) {
-			allCounts = append(allCounts, struct {
-				line  string
-				count int
-			}{currentLine, currentCount})
-			
-			countStr := fmt.Sprintf("%d", currentCount)
-			if len(countStr) > maxCountWidth {
-				maxCountWidth = len(countStr)
+			if err := uniqGrouping(cmd, &opts); err != nil {
+				return usageError(cmd, err)
 			}
-		}
+			locale, err := collationLocale(cmd)
+			if err != nil {
+				return err
+			}
+			opts.Locale = locale
 
-		// Second pass: print with proper alignment
-		for _, item := range allCounts {
-			if count {
-				fmt.Printf("%*d %s\n", maxCountWidth, item.count, item.line)
-			} else {
-				fmt.Println(item.line)
+			src, err := newLineSource(cmd, args)
+			if err != nil {
+				return err
 			}
+			defer src.Close()
+
+			if err := coreutils.Uniq(cmd.Context(), src.Concat(), cmd.OutOrStdout(), opts); err != nil {
+				return err
+			}
+			return sourceStatus(src)
+		},
+	}
+
+	cmd.Flags().BoolP("count", "c", false, "prefix lines with occurrence count")
+	cmd.Flags().BoolP("repeated", "d", false, "print only duplicate lines")
+	cmd.Flags().BoolP("unique", "u", false, "print only unique lines (non-repeated)")
+	cmd.Flags().StringP("all-repeated", "D", "", "print all duplicate lines, delimited by `METHOD`: none, prepend or separate")
+	cmd.Flags().Lookup("all-repeated").NoOptDefVal = "none"
+	cmd.Flags().String("group", "", "show all lines, groups delimited by `METHOD`: separate, prepend, append or both")
+	cmd.Flags().Lookup("group").NoOptDefVal = "separate"
+	cmd.Flags().IntP("skip-fields", "f", 0, "avoid comparing the first `N` fields")
+	cmd.Flags().IntP("skip-chars", "s", 0, "avoid comparing the first `N` characters")
+	cmd.Flags().IntP("check-chars", "w", 0, "compare no more than `N` characters in lines")
+	cmd.Flags().BoolP("ignore-case", "i", false, "ignore differences in case when comparing")
+	cmd.Flags().Bool("global", false, "collapse equal lines even when not adjacent (also --all)")
+	cmd.Flags().BoolP("zero-terminated", "z", false, "line delimiter is NUL, not newline")
+	addLocaleFlag(cmd)
+
+	cmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "all" {
+			name = "global"
 		}
-	},
+		return pflag.NormalizedName(name)
+	})
+
+	return cmd
 }
 
-func init() {
-	uniqCmd.Flags().BoolP("count", "c", false, "prefix lines with occurrence count")
-	uniqCmd.Flags().BoolP("repeated", "d", false, "print only duplicate lines")
-	uniqCmd.Flags().BoolP("unique", "u", false, "print only unique lines (non-repeated)")
+// uniqDelimits are the METHODs of --all-repeated and --group.
+var uniqDelimits = map[string]coreutils.UniqDelimit{
+	"none":     coreutils.UniqDelimitNone,
+	"separate": coreutils.UniqDelimitSeparate,
+	"prepend":  coreutils.UniqDelimitPrepend,
+	"append":   coreutils.UniqDelimitAppend,
+	"both":     coreutils.UniqDelimitBoth,
 }
 
-func shouldPrintLine(count int, showRepeated, showUnique bool) bool {
-	if showRepeated && count == 1 {
-		return false // Skip unique lines if only repeated are requested
-	}
-	if showUnique && count > 1 {
-		return false // Skip repeated lines if only unique are requested
+// uniqGrouping sets the AllRepeated, Group and Delimit options from
+// --all-repeated and --group, checking them against the other options.
+func uniqGrouping(cmd *cobra.Command, opts *coreutils.UniqOptions) error {
+	allRepeated, _ := cmd.Flags().GetString("all-repeated")
+	group, _ := cmd.Flags().GetString("group")
+	opts.AllRepeated = cmd.Flags().Changed("all-repeated")
+	opts.Group = cmd.Flags().Changed("group")
+
+	switch {
+	case opts.Group && (opts.Count || opts.Repeated || opts.AllRepeated || opts.Unique):
+		return fmt.Errorf("--group is mutually exclusive with -c/-d/-D/-u")
+	case opts.Group:
+		delimit, ok := uniqDelimits[group]
+		if !ok || delimit == coreutils.UniqDelimitNone {
+			return fmt.Errorf("invalid argument %q for '--group'", group)
+		}
+		opts.Delimit = delimit
+	case opts.AllRepeated && opts.Count:
+		return fmt.Errorf("printing all duplicated lines and repeat counts is meaningless")
+	case opts.AllRepeated:
+		delimit, ok := uniqDelimits[allRepeated]
+		if !ok || delimit > coreutils.UniqDelimitPrepend {
+			return fmt.Errorf("invalid argument %q for '--all-repeated'", allRepeated)
+		}
+		opts.Delimit = delimit
 	}
-	return true
+	return nil
 }
@@ -2,9 +2,10 @@ module github.com/monster0506/bashutils-go
 
 go 1.22.4
 
-require github.com/spf13/cobra v1.9.1
-
 require (
-	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
+	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
+	golang.org/x/text v0.22.0
 )
+
+require github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// AtomicFile is a file that replaces the file it is named after only once
+// it is complete. It is written to a temporary file in the same directory,
+// which Commit renames over the target, so readers of the target never see
+// it half written and the target can be read while its replacement is
+// being written.
+type AtomicFile struct {
+	*bufio.Writer
+
+	file   *os.File
+	target string
+	mode   fs.FileMode
+}
+
+// CreateAtomic starts a replacement for the named file. If the name is a
+// symbolic link, the file it points to is replaced. A replaced file keeps
+// its permissions; a new one is created with mode 0644. Targets that are
+// not regular files, like /dev/null or a pipe, cannot be replaced and are
+// written directly.
+func CreateAtomic(name string) (*AtomicFile, error) {
+	target, mode := name, fs.FileMode(0o644)
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		target = resolved
+		if info, err := os.Stat(target); err == nil {
+			if !info.Mode().IsRegular() {
+				f, err := os.OpenFile(target, os.O_WRONLY|os.O_TRUNC, 0)
+				if err != nil {
+					return nil, err
+				}
+				return &AtomicFile{Writer: bufio.NewWriterSize(f, 64*1024), file: f}, nil
+			}
+			mode = info.Mode().Perm()
+		}
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp")
+	if err != nil {
+		var pe *fs.PathError
+		if errors.As(err, &pe) {
+			err = &fs.PathError{Op: "open", Path: name, Err: pe.Err}
+		}
+		return nil, err
+	}
+	return &AtomicFile{Writer: bufio.NewWriterSize(f, 64*1024), file: f, target: target, mode: mode}, nil
+}
+
+// Commit flushes what has been written and puts it in place of the target.
+func (a *AtomicFile) Commit() error {
+	err := a.Flush()
+	if a.target == "" {
+		if cerr := a.file.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	if err == nil {
+		err = a.file.Chmod(a.mode)
+	}
+	if cerr := a.file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(a.file.Name(), a.target)
+	}
+	if err != nil {
+		os.Remove(a.file.Name())
+	}
+	return err
+}
+
+// Abort throws away what has been written, leaving the target as it was.
+// It does nothing after Commit.
+func (a *AtomicFile) Abort() {
+	if a.file.Close() == nil && a.target != "" {
+		os.Remove(a.file.Name())
+	}
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+)
+
+// Magic numbers of the compressed formats Decompress recognizes.
+var (
+	gzipMagic     = []byte{0x1f, 0x8b}
+	bzip2Magic    = []byte("BZh")
+	compressMagic = []byte{0x1f, 0x9d} // Unix compress (.Z), LZW
+
+	// A bzip2 stream goes on after "BZh" and the block size with the magic
+	// of its first block, the digits of pi, or with the end of stream
+	// magic, the digits of the square root of pi, if it is empty.
+	bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	bzip2EndMagic   = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+)
+
+// Decompress returns a reader that decodes r if it starts with the magic
+// number of a gzip, bzip2, zlib or Unix compress (.Z) stream, and that
+// returns r's content unchanged otherwise. Concatenated gzip members, as
+// written by log rotation, are read as one stream.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(10)
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case isBzip2Header(magic):
+		return bzip2.NewReader(br), nil
+	case bytes.HasPrefix(magic, compressMagic):
+		return newCompressReader(br)
+	case isZlibHeader(magic) && isZlibStream(br):
+		return zlib.NewReader(br)
+	}
+	return br, nil
+}
+
+// isBzip2Header reports whether b starts with the header of a bzip2
+// stream: "BZh", a block size from 1 to 9 and the magic of the first block
+// or of the end of the stream. Text may well start with "BZh".
+func isBzip2Header(b []byte) bool {
+	if len(b) < 10 || !bytes.HasPrefix(b, bzip2Magic) || b[3] < '1' || b[3] > '9' {
+		return false
+	}
+	return bytes.Equal(b[4:10], bzip2BlockMagic) || bytes.Equal(b[4:10], bzip2EndMagic)
+}
+
+// isZlibHeader reports whether b starts with a zlib header using deflate
+// and the default window size, at one of the compression levels written by
+// common zlib encoders: 78 01, 78 5E, 78 9C or 78 DA. Two bytes are still
+// easily plain text, like "x^", so isZlibStream has the last word.
+func isZlibHeader(b []byte) bool {
+	if len(b) < 2 || b[0] != 0x78 {
+		return false
+	}
+	switch b[1] {
+	case 0x01, 0x5e, 0x9c, 0xda:
+		return true
+	}
+	return false
+}
+
+// isZlibStream reports whether the data buffered in br, which starts with
+// a zlib header, decodes without error as far as it goes.
+func isZlibStream(br *bufio.Reader) bool {
+	data, _ := br.Peek(br.Size())
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	_, err = io.Copy(io.Discard, zr)
+	return err == nil || err == io.ErrUnexpectedEOF
+}
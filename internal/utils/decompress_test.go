@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecompress(t *testing.T) {
+	text := strings.Repeat("some log line\n", 2000)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(text))
+	gw.Close()
+
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"plain", []byte(text), text},
+		{"gzip", gz.Bytes(), text},
+		{"plain x^", []byte("x^2 + y^2 = 1\n"), "x^2 + y^2 = 1\n"},
+		{"plain x^ long", []byte("x^" + text), "x^" + text},
+		{"plain x with level byte", []byte("x\x01 not zlib\n"), "x\x01 not zlib\n"},
+		{"short", []byte("x"), "x"},
+		{"empty", nil, ""},
+		{"plain BZh", []byte("BZhello world\n"), "BZhello world\n"},
+		{"plain BZh and level", []byte("BZh9 blocks of text\n"), "BZh9 blocks of text\n"},
+		{"plain BZh short", []byte("BZh"), "BZh"},
+		{"bzip2", testdata(t, "sample.txt.bz2"), string(testdata(t, "sample.txt"))},
+		{"bzip2 empty", testdata(t, "empty.bz2"), ""},
+		{"compress", testdata(t, "sample.txt.Z"), string(testdata(t, "sample.txt"))},
+	}
+	for _, level := range []int{zlib.NoCompression, zlib.BestSpeed, zlib.DefaultCompression, zlib.BestCompression} {
+		var zb bytes.Buffer
+		zw, _ := zlib.NewWriterLevel(&zb, level)
+		zw.Write([]byte(text))
+		zw.Close()
+		tests = append(tests, struct {
+			name string
+			in   []byte
+			want string
+		}{fmt.Sprintf("zlib level %d", level), zb.Bytes(), text})
+	}
+
+	for _, tt := range tests {
+		r, err := Decompress(bytes.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("%s: Decompress: %v", tt.name, err)
+			continue
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Errorf("%s: read: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %d bytes %.20q, want %d bytes %.20q", tt.name, len(got), got, len(tt.want), tt.want)
+		}
+	}
+}
+
+// testdata returns the content of the named file in testdata. The
+// compressed samples were written by bzip2 and a Unix compress encoder
+// and check out with bzip2 -d and gzip -d.
+func testdata(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
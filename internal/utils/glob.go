@@ -1,119 +1,834 @@
-package utils
-
-import (
-	"fmt"
-	"os"
-	"path/filepath"
-	"sort"
-	"strings"
-	"regexp"
-)
-
-// ExpandGlobs takes a slice of arguments and expands any glob patterns
-// into matching file paths. It returns a new slice with globs expanded.
-func ExpandGlobs(args []string) ([]string, error) {
-	var expanded []string
-	
-	for _, arg := range args {
-		// Check if the argument contains any glob characters
-		if containsGlobChars(arg) {
-			matches, err := filepath.Glob(arg)
-			if err != nil {
-				return nil, fmt.Errorf("glob error for pattern %s: %v", arg, err)
-			}
-			
-			// If no matches found, keep the original pattern (bash behavior)
-			if len(matches) == 0 {
-				expanded = append(expanded, arg)
-				continue
-			}
-			
-			// Sort matches for consistent output
-			sort.Strings(matches)
-			expanded = append(expanded, matches...)
-		} else {
-			// No glob characters, add as-is
-			expanded = append(expanded, arg)
-		}
-	}
-	
-	return expanded, nil
-}
-
-// containsGlobChars checks if a string contains any glob pattern characters
-func containsGlobChars(s string) bool {
-	return strings.ContainsAny(s, "*?[")
-}
-
-// ExpandGlobsWithValidation expands globs and validates that files exist
-// It's similar to ExpandGlobs but filters out non-existent files
-func ExpandGlobsWithValidation(args []string) ([]string, error) {
-	expanded, err := ExpandGlobs(args)
-	if err != nil {
-		return nil, err
-	}
-	
-	var valid []string
-	for _, path := range expanded {
-		if _, err := os.Stat(path); err == nil {
-			valid = append(valid, path)
-		} else if os.IsNotExist(err) {
-			// Skip non-existent files
-			continue
-		} else {
-			// Return error for other issues (permission denied, etc.)
-			return nil, fmt.Errorf("error accessing %s: %v", path, err)
-		}
-	}
-	
-	return valid, nil
-}
-
-// ExpandGlobsForReading expands globs and returns only readable files
-func ExpandGlobsForReading(args []string) ([]string, error) {
-	expanded, err := ExpandGlobs(args)
-	if err != nil {
-		return nil, err
-	}
-	
-	var readable []string
-	for _, path := range expanded {
-		if file, err := os.Open(path); err == nil {
-			file.Close()
-			readable = append(readable, path)
-		} else if os.IsNotExist(err) {
-			// Skip non-existent files
-			continue
-		} else {
-			// Return error for other issues (permission denied, etc.)
-			return nil, fmt.Errorf("error accessing %s: %v", path, err)
-		}
-	}
-	
-	return readable, nil
-}
-
-// ExpandEnvironmentVariables expands both Unix-style ($VAR) and Windows-style (%VAR%) environment variables
-func ExpandEnvironmentVariables(input string) string {
-	// First expand Unix-style variables ($VAR)
-	unixRegex := regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
-	result := unixRegex.ReplaceAllStringFunc(input, func(match string) string {
-		varName := match[1:] // Remove the $ prefix
-		if value := os.Getenv(varName); value != "" {
-			return value
-		}
-		return match // Return original if not found
-	})
-
-	// Then expand Windows-style variables (%VAR%)
-	winRegex := regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_]*)%`)
-	result = winRegex.ReplaceAllStringFunc(result, func(match string) string {
-		varName := match[1 : len(match)-1] // Remove the % prefix and suffix
-		if value := os.Getenv(varName); value != "" {
-			return value
-		}
-		return match // Return original if not found
-	})
-
-	return result
-} 
\ No newline at end of file
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GlobOptions controls how patterns are expanded. The fields mirror the
+// bash shell options of the same names.
+type GlobOptions struct {
+	NullGlob   bool // patterns that match nothing expand to nothing
+	FailGlob   bool // patterns that match nothing are an error
+	DotGlob    bool // wildcards also match names starting with '.'
+	NoCaseGlob bool // patterns match without regard to case
+	GlobStar   bool // a ** path segment matches any number of directories
+}
+
+// GlobOptionsEnv names the environment variable holding default glob
+// options, as a colon-separated list like "nullglob:dotglob". A name
+// prefixed with "no" turns that option off, e.g. "noglobstar".
+const GlobOptionsEnv = "BASHUTILS_GLOBOPTS"
+
+// DefaultGlobOptions returns the options used when nothing else is
+// configured. Unlike bash, globstar is on by default.
+func DefaultGlobOptions() GlobOptions {
+	return GlobOptions{GlobStar: true}
+}
+
+var (
+	globOptionsMu sync.RWMutex
+	globOptions   = DefaultGlobOptions()
+)
+
+// SetGlobOptions replaces the options used by ExpandGlobs and the helpers
+// built on it. Commands share a single set, configured once at startup.
+func SetGlobOptions(opts GlobOptions) {
+	globOptionsMu.Lock()
+	defer globOptionsMu.Unlock()
+	globOptions = opts
+}
+
+// CurrentGlobOptions returns the options set by SetGlobOptions.
+func CurrentGlobOptions() GlobOptions {
+	globOptionsMu.RLock()
+	defer globOptionsMu.RUnlock()
+	return globOptions
+}
+
+// ParseGlobOptions applies a colon- or comma-separated list of option
+// names to opts and returns the result.
+func ParseGlobOptions(spec string, opts GlobOptions) (GlobOptions, error) {
+	for _, name := range strings.FieldsFunc(spec, func(r rune) bool { return r == ':' || r == ',' }) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		value := true
+		field := opts.field(name)
+		if field == nil && strings.HasPrefix(name, "no") {
+			value = false
+			field = opts.field(name[2:])
+		}
+		if field == nil {
+			return opts, fmt.Errorf("invalid glob option: %s", name)
+		}
+		*field = value
+	}
+	return opts, nil
+}
+
+func (o *GlobOptions) field(name string) *bool {
+	switch name {
+	case "nullglob":
+		return &o.NullGlob
+	case "failglob":
+		return &o.FailGlob
+	case "dotglob":
+		return &o.DotGlob
+	case "nocaseglob":
+		return &o.NoCaseGlob
+	case "globstar":
+		return &o.GlobStar
+	}
+	return nil
+}
+
+// ExpandGlobs takes a slice of arguments and expands any glob patterns
+// into matching file paths. It returns a new slice with globs expanded.
+// Patterns are matched according to CurrentGlobOptions.
+//
+// Each argument first goes through brace expansion ({a,b,c}, {1..10}),
+// then every resulting word containing glob characters is matched against
+// the file system. Besides the usual *, ? and [...], a path segment that
+// is exactly ** matches any number of directories, and the extended
+// patterns !(...), @(...), ?(...), *(...) and +(...) are understood.
+func ExpandGlobs(args []string) ([]string, error) {
+	return ExpandGlobsWithOptions(args, CurrentGlobOptions())
+}
+
+// ExpandGlobsWithOptions is like ExpandGlobs but uses opts instead of the
+// shared options.
+func ExpandGlobsWithOptions(args []string, opts GlobOptions) ([]string, error) {
+	var expanded []string
+
+	for _, arg := range args {
+		for _, word := range ExpandBraces(arg) {
+			// Check if the word contains any glob characters
+			if !containsGlobChars(word) {
+				expanded = append(expanded, word)
+				continue
+			}
+
+			matches := GlobWithOptions(word, opts)
+
+			// If no matches found, keep the original pattern (bash behavior)
+			// unless failglob or nullglob says otherwise
+			if len(matches) == 0 {
+				if opts.FailGlob {
+					return nil, fmt.Errorf("no match: %s", word)
+				}
+				if !opts.NullGlob {
+					expanded = append(expanded, word)
+				}
+				continue
+			}
+
+			// Sort matches for consistent output
+			sort.Strings(matches)
+			expanded = append(expanded, matches...)
+		}
+	}
+
+	return expanded, nil
+}
+
+// containsGlobChars checks if a string contains any glob pattern characters
+func containsGlobChars(s string) bool {
+	return strings.ContainsAny(s, "*?[") || strings.Contains(s, "!(") ||
+		strings.Contains(s, "@(") || strings.Contains(s, "+(")
+}
+
+// Glob returns the paths matching pattern, in no particular order. It
+// returns nil when nothing matches. Like bash, a leading dot in a file name
+// must be matched explicitly unless dotglob is set, and ** does not descend
+// into hidden directories or follow symbolic links.
+func Glob(pattern string) []string {
+	return GlobWithOptions(pattern, CurrentGlobOptions())
+}
+
+// GlobWithOptions is like Glob but uses opts instead of the shared options.
+func GlobWithOptions(pattern string, opts GlobOptions) []string {
+	vol := filepath.VolumeName(pattern)
+	rest := pattern[len(vol):]
+
+	base := vol
+	if len(rest) > 0 && isPathSeparator(rest[0]) {
+		base += rest[:1]
+		rest = rest[1:]
+	}
+
+	segments := splitPath(rest)
+	seen := make(map[string]bool)
+	var matches []string
+	g := globber{opts: opts}
+	g.segments(base, segments, func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			matches = append(matches, path)
+		}
+	})
+	return matches
+}
+
+// MatchGlob reports whether name matches the single-segment glob pattern.
+// It supports the same syntax as a path segment given to Glob, except
+// that ** behaves like *. The nocaseglob option is honored.
+func MatchGlob(pattern, name string) bool {
+	return compileGlob(pattern, CurrentGlobOptions().NoCaseGlob).match([]rune(name))
+}
+
+type globber struct {
+	opts GlobOptions
+}
+
+// hidden reports whether name should be skipped by wildcards that do not
+// start with an explicit '.'.
+func (g globber) hidden(name string) bool {
+	return strings.HasPrefix(name, ".") && !g.opts.DotGlob
+}
+
+func (g globber) segments(base string, segments []string, emit func(string)) {
+	if len(segments) == 0 {
+		emit(base)
+		return
+	}
+	seg, rest := segments[0], segments[1:]
+
+	// A trailing separator only matches directories.
+	if seg == "" {
+		if len(rest) == 0 {
+			if isDir(base) {
+				emit(joinPath(base, ""))
+			}
+			return
+		}
+		g.segments(base, rest, emit)
+		return
+	}
+
+	if seg == "**" && g.opts.GlobStar {
+		dirs := []string{base}
+		g.walkDirs(base, func(dir string, entries []os.DirEntry) {
+			for _, e := range entries {
+				if g.hidden(e.Name()) {
+					continue
+				}
+				path := joinPath(dir, e.Name())
+				if len(rest) == 0 {
+					emit(path)
+				}
+				if e.IsDir() {
+					dirs = append(dirs, path)
+				}
+			}
+		})
+		if len(rest) == 0 {
+			return
+		}
+		for _, dir := range dirs {
+			g.segments(dir, rest, emit)
+		}
+		return
+	}
+
+	if !containsGlobChars(seg) {
+		path := joinPath(base, unescapeGlob(seg))
+		if _, err := os.Lstat(path); err != nil {
+			return
+		}
+		g.segments(path, rest, emit)
+		return
+	}
+
+	entries, err := os.ReadDir(dirOrDot(base))
+	if err != nil {
+		return
+	}
+	m := compileGlob(seg, g.opts.NoCaseGlob)
+	explicitDot := strings.HasPrefix(seg, ".")
+	for _, e := range entries {
+		name := e.Name()
+		if g.hidden(name) && !explicitDot {
+			continue
+		}
+		if !m.match([]rune(name)) {
+			continue
+		}
+		path := joinPath(base, name)
+		if len(rest) > 0 && !isDir(path) {
+			continue
+		}
+		g.segments(path, rest, emit)
+	}
+}
+
+// walkDirs calls fn for dir and every non-hidden directory below it, in
+// lexical order, passing the directory's entries.
+func (g globber) walkDirs(dir string, fn func(dir string, entries []os.DirEntry)) {
+	entries, err := os.ReadDir(dirOrDot(dir))
+	if err != nil {
+		return
+	}
+	fn(dir, entries)
+	for _, e := range entries {
+		if e.IsDir() && !g.hidden(e.Name()) {
+			g.walkDirs(joinPath(dir, e.Name()), fn)
+		}
+	}
+}
+
+func isPathSeparator(c byte) bool {
+	return c == '/' || c == filepath.Separator
+}
+
+func splitPath(s string) []string {
+	var segments []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if isPathSeparator(s[i]) {
+			// Collapse repeated separators, but keep a trailing one.
+			if i > start || i == len(s)-1 {
+				segments = append(segments, s[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(s) {
+		segments = append(segments, s[start:])
+	} else if len(s) > 0 {
+		segments = append(segments, "")
+	}
+	return segments
+}
+
+func joinPath(base, name string) string {
+	if base == "" {
+		return name
+	}
+	if isPathSeparator(base[len(base)-1]) {
+		return base + name
+	}
+	return base + string(filepath.Separator) + name
+}
+
+func dirOrDot(dir string) string {
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(dirOrDot(path))
+	return err == nil && info.IsDir()
+}
+
+// globEscapes reports whether backslash escapes the next pattern character.
+// On Windows the backslash is a path separator instead.
+const globEscapes = filepath.Separator != '\\'
+
+func unescapeGlob(s string) string {
+	if !globEscapes || !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+type globTokenKind int
+
+const (
+	globLiteral globTokenKind = iota
+	globAny
+	globStar
+	globClass
+	globGroup
+)
+
+type globToken struct {
+	kind  globTokenKind
+	r     rune
+	class *globCharClass
+	op    rune          // one of ! @ ? * + for groups
+	alts  [][]globToken // alternatives of an extended group
+}
+
+type globCharClass struct {
+	negate bool
+	ranges [][2]rune
+	named  []func(rune) bool
+}
+
+func (c *globCharClass) contains(r rune, fold bool) bool {
+	if fold {
+		return c.contains(unicode.ToLower(r), false) || c.contains(unicode.ToUpper(r), false)
+	}
+	found := false
+	for _, rg := range c.ranges {
+		if r >= rg[0] && r <= rg[1] {
+			found = true
+			break
+		}
+	}
+	if !found {
+		for _, fn := range c.named {
+			if fn(r) {
+				found = true
+				break
+			}
+		}
+	}
+	return found != c.negate
+}
+
+type globMatcher struct {
+	tokens []globToken
+	fold   bool
+}
+
+func compileGlob(pattern string, fold bool) *globMatcher {
+	tokens, _ := parseGlob([]rune(pattern), 0, false)
+	return &globMatcher{tokens: tokens, fold: fold}
+}
+
+// parseGlob parses p starting at pos until the end of input or, inside an
+// extended group, until an unnested '|' or ')'.
+func parseGlob(p []rune, pos int, inGroup bool) ([]globToken, int) {
+	var tokens []globToken
+	for pos < len(p) {
+		c := p[pos]
+		if inGroup && (c == '|' || c == ')') {
+			break
+		}
+
+		if strings.ContainsRune("!@?*+", c) && pos+1 < len(p) && p[pos+1] == '(' {
+			if alts, end, ok := parseGlobGroup(p, pos+2); ok {
+				tokens = append(tokens, globToken{kind: globGroup, op: c, alts: alts})
+				pos = end
+				continue
+			}
+		}
+
+		switch c {
+		case '\\':
+			if globEscapes && pos+1 < len(p) {
+				pos++
+			}
+			tokens = append(tokens, globToken{kind: globLiteral, r: p[pos]})
+			pos++
+		case '*':
+			if n := len(tokens); n == 0 || tokens[n-1].kind != globStar {
+				tokens = append(tokens, globToken{kind: globStar})
+			}
+			pos++
+		case '?':
+			tokens = append(tokens, globToken{kind: globAny})
+			pos++
+		case '[':
+			if class, end, ok := parseGlobClass(p, pos+1); ok {
+				tokens = append(tokens, globToken{kind: globClass, class: class})
+				pos = end
+				continue
+			}
+			// An unterminated bracket is an ordinary character, as in bash.
+			tokens = append(tokens, globToken{kind: globLiteral, r: c})
+			pos++
+		default:
+			tokens = append(tokens, globToken{kind: globLiteral, r: c})
+			pos++
+		}
+	}
+	return tokens, pos
+}
+
+func parseGlobGroup(p []rune, pos int) ([][]globToken, int, bool) {
+	var alts [][]globToken
+	for {
+		var alt []globToken
+		alt, pos = parseGlob(p, pos, true)
+		alts = append(alts, alt)
+		if pos >= len(p) {
+			return nil, 0, false
+		}
+		if p[pos] == ')' {
+			return alts, pos + 1, true
+		}
+		pos++ // skip '|'
+	}
+}
+
+var globNamedClasses = map[string]func(rune) bool{
+	"alnum":  func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) },
+	"alpha":  unicode.IsLetter,
+	"blank":  func(r rune) bool { return r == ' ' || r == '\t' },
+	"cntrl":  unicode.IsControl,
+	"digit":  unicode.IsDigit,
+	"graph":  func(r rune) bool { return unicode.IsGraphic(r) && !unicode.IsSpace(r) },
+	"lower":  unicode.IsLower,
+	"print":  unicode.IsPrint,
+	"punct":  unicode.IsPunct,
+	"space":  unicode.IsSpace,
+	"upper":  unicode.IsUpper,
+	"xdigit": func(r rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", r) },
+}
+
+// parseGlobClass parses a bracket expression whose opening '[' has already
+// been consumed. It returns the position just past the closing ']'.
+func parseGlobClass(p []rune, pos int) (*globCharClass, int, bool) {
+	class := &globCharClass{}
+	if pos < len(p) && (p[pos] == '!' || p[pos] == '^') {
+		class.negate = true
+		pos++
+	}
+	first := true
+	for pos < len(p) {
+		c := p[pos]
+		if c == ']' && !first {
+			return class, pos + 1, true
+		}
+		first = false
+
+		if c == '[' && pos+1 < len(p) && p[pos+1] == ':' {
+			if end := indexRunes(p[pos+2:], ":]"); end >= 0 {
+				name := string(p[pos+2 : pos+2+end])
+				if fn, ok := globNamedClasses[name]; ok {
+					class.named = append(class.named, fn)
+					pos += end + 4
+					continue
+				}
+			}
+		}
+
+		if c == '\\' && globEscapes && pos+1 < len(p) {
+			pos++
+			c = p[pos]
+		}
+		lo, hi := c, c
+		if pos+2 < len(p) && p[pos+1] == '-' && p[pos+2] != ']' {
+			hi = p[pos+2]
+			pos += 2
+		}
+		class.ranges = append(class.ranges, [2]rune{lo, hi})
+		pos++
+	}
+	return nil, 0, false
+}
+
+func indexRunes(p []rune, sub string) int {
+	s := []rune(sub)
+	for i := 0; i+len(s) <= len(p); i++ {
+		if string(p[i:i+len(s)]) == sub {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *globMatcher) match(s []rune) bool {
+	return m.matchTokens(m.tokens, s)
+}
+
+func (m *globMatcher) matchTokens(tokens []globToken, s []rune) bool {
+	for len(tokens) > 0 {
+		t := tokens[0]
+		switch t.kind {
+		case globLiteral:
+			if len(s) == 0 || !m.equal(s[0], t.r) {
+				return false
+			}
+		case globAny:
+			if len(s) == 0 {
+				return false
+			}
+		case globClass:
+			if len(s) == 0 || !t.class.contains(s[0], m.fold) {
+				return false
+			}
+		case globStar:
+			for i := 0; i <= len(s); i++ {
+				if m.matchTokens(tokens[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case globGroup:
+			return m.matchGroup(t, tokens[1:], s)
+		}
+		tokens, s = tokens[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+func (m *globMatcher) equal(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	return m.fold && unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+func (m *globMatcher) matchGroup(t globToken, rest []globToken, s []rune) bool {
+	for i := 0; i <= len(s); i++ {
+		head, tail := s[:i], s[i:]
+		var ok bool
+		switch t.op {
+		case '!':
+			ok = !m.matchAlts(t.alts, head)
+		case '@':
+			ok = m.matchAlts(t.alts, head)
+		case '?':
+			ok = len(head) == 0 || m.matchAlts(t.alts, head)
+		case '*':
+			ok = m.matchRepeat(t.alts, head)
+		case '+':
+			ok = len(head) > 0 && m.matchRepeat(t.alts, head)
+		}
+		if ok && m.matchTokens(rest, tail) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *globMatcher) matchAlts(alts [][]globToken, s []rune) bool {
+	for _, alt := range alts {
+		if m.matchTokens(alt, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlobRepeat reports whether s is a concatenation of zero or more
+// strings each matching one of alts.
+func (m *globMatcher) matchRepeat(alts [][]globToken, s []rune) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 1; i <= len(s); i++ {
+		if m.matchAlts(alts, s[:i]) && m.matchRepeat(alts, s[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandBraces performs bash-style brace expansion on s. Comma lists
+// ({a,b,c}) and sequences ({1..10}, {a..e}, {01..10..2}) are expanded,
+// nesting is allowed, and a brace preceded by a backslash is left alone.
+// A string without a valid brace expression is returned unchanged.
+func ExpandBraces(s string) []string {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			continue
+		case '{':
+		default:
+			continue
+		}
+
+		end := matchingBrace(s, i)
+		if end < 0 {
+			continue
+		}
+		inner := s[i+1 : end]
+
+		alts := splitBraceList(inner)
+		if len(alts) < 2 {
+			seq, ok := braceSequence(inner)
+			if !ok {
+				continue
+			}
+			alts = seq
+		}
+
+		pre := s[:i]
+		posts := ExpandBraces(s[end+1:])
+		var out []string
+		for _, alt := range alts {
+			for _, a := range ExpandBraces(alt) {
+				for _, p := range posts {
+					out = append(out, pre+a+p)
+				}
+			}
+		}
+		return out
+	}
+	return []string{s}
+}
+
+// matchingBrace returns the index of the '}' closing the '{' at open, or
+// -1 if it is unbalanced.
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitBraceList splits the body of a brace expression on commas that are
+// not nested inside another pair of braces.
+func splitBraceList(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+var braceSequenceRe = regexp.MustCompile(`^(-?\d+|[^.\d])\.\.(-?\d+|[^.\d])(?:\.\.(-?\d+))?$`)
+
+// braceSequence expands the body of a sequence expression such as 1..10,
+// 10..1..3 or a..e.
+func braceSequence(s string) ([]string, bool) {
+	m := braceSequenceRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+
+	step := uint64(1)
+	if m[3] != "" {
+		n, err := strconv.Atoi(m[3])
+		if err != nil {
+			return nil, false
+		}
+		if n < 0 {
+			step = uint64(-n)
+		} else if n > 0 {
+			step = uint64(n)
+		}
+	}
+
+	start, errStart := strconv.Atoi(m[1])
+	end, errEnd := strconv.Atoi(m[2])
+	if errStart == nil && errEnd == nil {
+		width := 0
+		if hasLeadingZero(m[1]) || hasLeadingZero(m[2]) {
+			width = max(len(m[1]), len(m[2]))
+		}
+		seq, ok := sequence(start, end, step)
+		if !ok {
+			return nil, false
+		}
+		out := make([]string, len(seq))
+		for i, n := range seq {
+			out[i] = fmt.Sprintf("%0*d", width, n)
+		}
+		return out, true
+	}
+
+	// Numbers too large for an int are not characters either.
+	if errStart == nil || errEnd == nil || utf8.RuneCountInString(m[1]) != 1 || utf8.RuneCountInString(m[2]) != 1 {
+		return nil, false
+	}
+	lo, _ := utf8.DecodeRuneInString(m[1])
+	hi, _ := utf8.DecodeRuneInString(m[2])
+	seq, ok := sequence(int(lo), int(hi), step)
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, len(seq))
+	for i, r := range seq {
+		out[i] = string(rune(r))
+	}
+	return out, true
+}
+
+func hasLeadingZero(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	return len(s) > 1 && s[0] == '0'
+}
+
+// maxBraceSequence is the most words a sequence expression expands to. A
+// longer one is left as it is written rather than exhausting memory.
+const maxBraceSequence = 1 << 20
+
+// sequence returns the numbers from start to end, counting up or down by
+// step, which must be positive. It reports false if there are more than
+// maxBraceSequence of them. The count is worked out first, in unsigned
+// arithmetic, so ranges near the limits of int cannot overflow.
+func sequence(start, end int, step uint64) ([]int, bool) {
+	span := uint64(end) - uint64(start)
+	if start > end {
+		span = uint64(start) - uint64(end)
+	}
+	count := span/step + 1
+	if count > maxBraceSequence {
+		return nil, false
+	}
+
+	out := make([]int, count)
+	for i := range out {
+		offset := uint64(i) * step
+		if start <= end {
+			out[i] = int(uint64(start) + offset)
+		} else {
+			out[i] = int(uint64(start) - offset)
+		}
+	}
+	return out, true
+}
+
+// ExpandEnvironmentVariables expands both Unix-style ($VAR) and Windows-style (%VAR%) environment variables
+func ExpandEnvironmentVariables(input string) string {
+	// First expand Unix-style variables ($VAR)
+	unixRegex := regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+	result := unixRegex.ReplaceAllStringFunc(input, func(match string) string {
+		varName := match[1:] // Remove the $ prefix
+		if value := os.Getenv(varName); value != "" {
+			return value
+		}
+		return match // Return original if not found
+	})
+
+	// Then expand Windows-style variables (%VAR%)
+	winRegex := regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_]*)%`)
+	result = winRegex.ReplaceAllStringFunc(result, func(match string) string {
+		varName := match[1 : len(match)-1] // Remove the % prefix and suffix
+		if value := os.Getenv(varName); value != "" {
+			return value
+		}
+		return match // Return original if not found
+	})
+
+	return result
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		fold          bool
+		want          bool
+	}{
+		{"*", "", false, true},
+		{"*", "abc", false, true},
+		{"a*c", "abbbc", false, true},
+		{"a*c", "abcd", false, false},
+		{"*.go", "main.go", false, true},
+		{"*.go", "main.goo", false, false},
+		{"?", "é", false, true},
+		{"??", "a", false, false},
+		{"a**b", "axxb", false, true},
+		{`a\*b`, "a*b", false, true},
+		{`a\*b`, "axb", false, false},
+		{"[abc]", "b", false, true},
+		{"[!abc]", "b", false, false},
+		{"[^abc]", "d", false, true},
+		{"[a-c]x", "cx", false, true},
+		{"[a-c]x", "dx", false, false},
+		{"[]a]", "]", false, true},
+		{"[a-]", "-", false, true},
+		{"[[:digit:]][[:alpha:]]", "1z", false, true},
+		{"[[:upper:]]", "a", false, false},
+		{"[[:space:]]", "\t", false, true},
+		{"[abc", "[abc", false, true},
+		{"ABC", "abc", false, false},
+		{"ABC", "abc", true, true},
+		{"[A-C]", "b", true, true},
+		{"@(foo|bar).txt", "bar.txt", false, true},
+		{"@(foo|bar).txt", "baz.txt", false, false},
+		{"!(foo).txt", "bar.txt", false, true},
+		{"!(foo).txt", "foo.txt", false, false},
+		{"!(*.go)", "main.go", false, false},
+		{"!(*.go)", "main.rs", false, true},
+		{"?(a)b", "b", false, true},
+		{"?(a)b", "ab", false, true},
+		{"?(a)b", "aab", false, false},
+		{"*(ab)c", "c", false, true},
+		{"*(ab)c", "ababc", false, true},
+		{"*(ab)c", "abac", false, false},
+		{"+(ab)c", "c", false, false},
+		{"+(ab|x)c", "abxabc", false, true},
+		{"@(a|b*(c))d", "bcccd", false, true},
+		{"@(foo", "@(foo", false, true},
+	}
+	for _, tt := range tests {
+		got := compileGlob(tt.pattern, tt.fold).match([]rune(tt.name))
+		if got != tt.want {
+			t.Errorf("match(%q, %q, fold=%v) = %v, want %v", tt.pattern, tt.name, tt.fold, got, tt.want)
+		}
+	}
+}
+
+func TestMatchGlobBacktracking(t *testing.T) {
+	// Patterns that backtrack a lot must still finish and answer right.
+	name := []rune("aaaaaaaaaaaaaaaaaaaaaaaaaaaaab")
+	if compileGlob("*a*a*a*a*a*a*a*c", false).match(name) {
+		t.Error("*a*a*a*a*a*a*a*c matched a name without c")
+	}
+	if !compileGlob("+(a|aa)b", false).match(name) {
+		t.Error("+(a|aa)b did not match")
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"plain", []string{"plain"}},
+		{"a{b,c}d", []string{"abd", "acd"}},
+		{"{a,b}{1,2}", []string{"a1", "a2", "b1", "b2"}},
+		{"x{a,{b,c}}y", []string{"xay", "xby", "xcy"}},
+		{"{a,}", []string{"a", ""}},
+		{"{a}", []string{"{a}"}},
+		{"{a,b", []string{"{a,b"}},
+		{`\{a,b}`, []string{`\{a,b}`}},
+		{"f{1..3}", []string{"f1", "f2", "f3"}},
+		{"{3..1}", []string{"3", "2", "1"}},
+		{"{1..10..4}", []string{"1", "5", "9"}},
+		{"{01..10..3}", []string{"01", "04", "07", "10"}},
+		{"{-2..1}", []string{"-2", "-1", "0", "1"}},
+		{"{a..e..2}", []string{"a", "c", "e"}},
+		{"{1..a}", []string{"{1..a}"}},
+		{"{1..3}{a,b}", []string{"1a", "1b", "2a", "2b", "3a", "3b"}},
+		{"{9223372036854775806..9223372036854775807}", []string{"9223372036854775806", "9223372036854775807"}},
+		{"{9223372036854775807..9223372036854775806}", []string{"9223372036854775807", "9223372036854775806"}},
+		{"{-9223372036854775807..-9223372036854775808}", []string{"-9223372036854775807", "-9223372036854775808"}},
+		{"{9223372036854775800..9223372036854775807..5}", []string{"9223372036854775800", "9223372036854775805"}},
+		{"{-9223372036854775808..9223372036854775807..9223372036854775807}",
+			[]string{"-9223372036854775808", "-1", "9223372036854775806"}},
+		{"{0..9223372036854775807..-9223372036854775808}", []string{"0"}},
+		{"{1..2..0}", []string{"1", "2"}},
+		{"{1..9223372036854775807}", []string{"{1..9223372036854775807}"}},
+		{"{99999999999999999999..99999999999999999998}", []string{"{99999999999999999999..99999999999999999998}"}},
+	}
+	for _, tt := range tests {
+		got := ExpandBraces(tt.in)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExpandBraces(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseGlobOptions(t *testing.T) {
+	opts, err := ParseGlobOptions("nullglob:dotglob, noglobstar", DefaultGlobOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := GlobOptions{NullGlob: true, DotGlob: true}
+	if opts != want {
+		t.Errorf("got %+v, want %+v", opts, want)
+	}
+	if _, err := ParseGlobOptions("nosuchglob", opts); err == nil {
+		t.Error("invalid option accepted")
+	}
+}
+
+// globTree creates the files under a temporary directory and makes it the
+// current directory for the rest of the test.
+func globTree(t *testing.T, files ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestExpandGlobsWithOptions(t *testing.T) {
+	globTree(t,
+		"a.go", "b.go", "c.txt", ".hidden.go",
+		"sub/d.go", "sub/deep/e.go", "sub/deep/f.txt", ".git/g.go",
+	)
+	tests := []struct {
+		args []string
+		opts GlobOptions
+		want []string
+	}{
+		{[]string{"*.go"}, GlobOptions{}, []string{"a.go", "b.go"}},
+		{[]string{"*.go"}, GlobOptions{DotGlob: true}, []string{".hidden.go", "a.go", "b.go"}},
+		{[]string{".*.go"}, GlobOptions{}, []string{".hidden.go"}},
+		{[]string{"*.GO"}, GlobOptions{NoCaseGlob: true}, []string{"a.go", "b.go"}},
+		{[]string{"**/*.go"}, GlobOptions{GlobStar: true},
+			[]string{"a.go", "b.go", "sub/d.go", "sub/deep/e.go"}},
+		{[]string{"**/*.go"}, GlobOptions{},
+			[]string{"sub/d.go"}},
+		{[]string{"sub/**"}, GlobOptions{GlobStar: true},
+			[]string{"sub/d.go", "sub/deep", "sub/deep/e.go", "sub/deep/f.txt"}},
+		{[]string{"*/"}, GlobOptions{}, []string{"sub/"}},
+		{[]string{"{a,c}.*"}, GlobOptions{}, []string{"a.go", "c.txt"}},
+		{[]string{"!(*.go)"}, GlobOptions{}, []string{"c.txt", "sub"}},
+		{[]string{"*.md"}, GlobOptions{}, []string{"*.md"}},
+		{[]string{"*.md", "c.txt"}, GlobOptions{NullGlob: true}, []string{"c.txt"}},
+		{[]string{"missing.txt"}, GlobOptions{NullGlob: true}, []string{"missing.txt"}},
+	}
+	for _, tt := range tests {
+		got, err := ExpandGlobsWithOptions(tt.args, tt.opts)
+		if err != nil {
+			t.Errorf("ExpandGlobsWithOptions(%q, %+v): %v", tt.args, tt.opts, err)
+			continue
+		}
+		for i := range got {
+			got[i] = filepath.ToSlash(got[i])
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExpandGlobsWithOptions(%q, %+v) = %q, want %q", tt.args, tt.opts, got, tt.want)
+		}
+	}
+
+	if _, err := ExpandGlobsWithOptions([]string{"*.md"}, GlobOptions{FailGlob: true}); err == nil {
+		t.Error("failglob: no error for a pattern matching nothing")
+	}
+}
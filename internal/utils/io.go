@@ -1,39 +1,14 @@
 package utils
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 )
 
-func ReadAllFromFilesOrStdin(files []string) (string, error) {
-	if len(files) == 0 {
-		return ReadAllFromReader(os.Stdin)
-	}
-	
-	// Expand glob patterns in file arguments
-	expandedFiles, err := ExpandGlobsForReading(files)
-	if err != nil {
-		return "", err
-	}
-	
-	var sb strings.Builder
-	for _, f := range expandedFiles {
-		file, err := os.Open(f)
-		if err != nil {
-			return "", fmt.Errorf("%s: %v", f, err)
-		}
-		defer file.Close()
-		content, err := ReadAllFromReader(file)
-		if err != nil {
-			return "", err
-		}
-		sb.WriteString(content)
-	}
-	return sb.String(), nil
-}
+// StdinName is the file operand that stands for standard input.
+const StdinName = "-"
 
 func ReadAllFromReader(r io.Reader) (string, error) {
 	b, err := io.ReadAll(r)
@@ -44,43 +19,299 @@ func ReadAllFromReader(r io.Reader) (string, error) {
 }
 
 func ReadLines(r io.Reader) ([]string, error) {
-	scanner := bufio.NewScanner(r)
+	lr := NewLineReader(r, '\n')
 	lines := []string{}
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	for lr.Scan() {
+		lines = append(lines, strings.TrimSuffix(lr.Text(), "\r"))
 	}
-	return lines, scanner.Err()
+	return lines, lr.Err()
+}
+
+// OpenInput opens the named file for reading, or returns stdin (os.Stdin
+// if nil) for the operand "-". With decompress, a compressed file is
+// decoded as it is read.
+func OpenInput(name string, stdin io.Reader, decompress bool) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	if name == StdinName {
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		rc = io.NopCloser(stdin)
+	} else {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		rc = f
+	}
+
+	if !decompress {
+		return rc, nil
+	}
+	r, err := Decompress(rc)
+	if err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return readCloser{r, rc}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Record is a single line read from a LineSource, together with the file it
+// came from and its 1-based line number within that file.
+type Record struct {
+	Text string
+	File string
+	Line int
+}
+
+// LineSource streams lines from a list of file operands, or from standard
+// input when the list is empty. The operand "-" also names standard input.
+//
+// A file that cannot be opened or read is passed to OnError and skipped, so
+// one bad file does not stop the rest from being processed. Failed reports
+// whether that happened.
+//
+// Lines can be read across all files with Next, or file by file:
+//
+//	for src.NextFile() {
+//		for src.Scan() {
+//			rec := src.Record()
+//		}
+//	}
+type LineSource struct {
+	// OnError is called for every file that cannot be opened or read.
+	OnError func(name string, err error)
+	// Stdin is read for the "-" operand. It defaults to os.Stdin.
+	Stdin io.Reader
+	// Decompress makes compressed files readable as their content; see
+	// Decompress.
+	Decompress bool
+	// Files are decoded with a TextReader, so lines never end in CR and
+	// UTF-16 arrives as UTF-8. KeepCR keeps the CR of CRLF line endings,
+	// and Binary turns the decoding off so Reader returns the files' bytes
+	// unchanged.
+	KeepCR bool
+	Binary bool
+	// ZeroTerminated makes lines end with NUL instead of newline. Such
+	// records hold data like file names rather than text, so it implies
+	// Binary.
+	ZeroTerminated bool
+
+	names   []string
+	next    int
+	name    string
+	file    io.Closer
+	reader  io.Reader
+	text    *TextReader
+	ending  LineEnding
+	scanner *LineReader
+	rec     Record
+	failed  bool
 }
 
-// ReadLinesFromFilesOrStdin reads lines from files or stdin if no files provided
-// This is the common pattern used by commands like sort, uniq, grep, etc.
-func ReadLinesFromFilesOrStdin(files []string) ([]string, error) {
-	if len(files) == 0 {
-		// Read from stdin when no files provided
-		return ReadLines(os.Stdin)
+// NewLineSource expands glob patterns in args and returns a source reading
+// the resulting files in order.
+func NewLineSource(args []string) (*LineSource, error) {
+	if len(args) == 0 {
+		return &LineSource{names: []string{StdinName}}, nil
 	}
-	
-	// Expand glob patterns in file arguments
-	expandedFiles, err := ExpandGlobsForReading(files)
+
+	expanded, err := ExpandGlobs(args)
 	if err != nil {
 		return nil, err
 	}
-	
-	var allLines []string
-	for _, path := range expandedFiles {
-		file, err := os.Open(path)
+	return &LineSource{names: expanded}, nil
+}
+
+// NewLineSourceFiles returns a source reading the named files in order,
+// taking the names literally. Unlike NewLineSource, it reads nothing when
+// names is empty.
+func NewLineSourceFiles(names []string) *LineSource {
+	return &LineSource{names: names}
+}
+
+// NextFile closes the current file and opens the next one that can be
+// opened. It returns false when there are no files left.
+func (s *LineSource) NextFile() bool {
+	s.closeFile()
+	for s.next < len(s.names) {
+		name := s.names[s.next]
+		s.next++
+
+		f, err := OpenInput(name, s.Stdin, s.Decompress)
 		if err != nil {
-			return nil, fmt.Errorf("%s: %v", path, err)
+			s.fail(name, err)
+			continue
 		}
-		
-		lines, err := ReadLines(file)
-		file.Close()
-		if err != nil {
-			return nil, fmt.Errorf("%s: %v", path, err)
+		s.file = f
+		s.reader = f
+		if !s.Binary && !s.ZeroTerminated {
+			s.text = NewTextReader(f, s.KeepCR)
+			s.reader = s.text
+		}
+
+		s.name = name
+		if s.ZeroTerminated {
+			s.scanner = NewLineReader(s.reader, 0)
+		} else {
+			s.scanner = NewLineReader(s.reader, '\n')
+		}
+		s.rec = Record{File: name}
+		return true
+	}
+	return false
+}
+
+// NumFiles returns the number of file operands after glob expansion,
+// including any that turn out not to be readable.
+func (s *LineSource) NumFiles() int {
+	return len(s.names)
+}
+
+// Name returns the operand naming the current file.
+func (s *LineSource) Name() string {
+	return s.name
+}
+
+// Reader returns the current file, decoded unless Binary is set, for
+// callers that read it as a whole rather than line by line. It must not be
+// mixed with Scan for the same file.
+func (s *LineSource) Reader() io.Reader {
+	return s.reader
+}
+
+// Concat returns a reader over the rest of the current file followed by
+// the remaining files, for callers that treat every operand as one stream.
+// A line terminator is added after a file that does not end with one, so
+// lines never run together across files.
+func (s *LineSource) Concat() io.Reader {
+	delim := byte('\n')
+	if s.ZeroTerminated {
+		delim = 0
+	}
+	return &concatReader{src: s, open: s.reader != nil, delim: delim}
+}
+
+type concatReader struct {
+	src  *LineSource
+	open bool // src.reader is the file being read
+	data bool // the current file has produced bytes
+	last byte // last byte read from the current file
+
+	delim byte
+}
+
+func (c *concatReader) Read(p []byte) (int, error) {
+	for {
+		if !c.open {
+			if !c.src.NextFile() {
+				return 0, io.EOF
+			}
+			c.open, c.data = true, false
+		}
+
+		n, err := c.src.reader.Read(p)
+		if n > 0 {
+			c.data, c.last = true, p[n-1]
+			return n, nil
+		}
+		if err == nil {
+			continue
+		}
+		if err != io.EOF {
+			c.src.fail(c.src.name, err)
+		}
+		c.src.closeFile()
+		c.open = false
+		if c.data && c.last != c.delim && len(p) > 0 {
+			p[0] = c.delim
+			return 1, nil
+		}
+	}
+}
+
+// LineEnding returns the line terminator style of the first file that had
+// one, as far as the files have been read. It is LineEndingUnknown when
+// Binary is set.
+func (s *LineSource) LineEnding() LineEnding {
+	if s.ending == LineEndingUnknown && s.text != nil {
+		s.ending = s.text.LineEnding()
+	}
+	return s.ending
+}
+
+// Scan advances to the next line of the current file.
+func (s *LineSource) Scan() bool {
+	if s.scanner == nil {
+		return false
+	}
+	if s.scanner.Scan() {
+		s.rec.Text = s.scanner.Text()
+		s.rec.Line++
+		return true
+	}
+	if err := s.scanner.Err(); err != nil {
+		s.fail(s.name, err)
+	}
+	s.scanner = nil
+	return false
+}
+
+// Next advances to the next line, moving on to the following files as each
+// one is exhausted.
+func (s *LineSource) Next() bool {
+	for {
+		if s.Scan() {
+			return true
 		}
-		
-		allLines = append(allLines, lines...)
+		if !s.NextFile() {
+			return false
+		}
+	}
+}
+
+// Record returns the line most recently read by Scan or Next.
+func (s *LineSource) Record() Record {
+	return s.rec
+}
+
+// Fail records an error for the named file, for callers reading through
+// Reader that hit a problem of their own.
+func (s *LineSource) Fail(name string, err error) {
+	s.fail(name, err)
+}
+
+// Failed reports whether any file could not be opened or read.
+func (s *LineSource) Failed() bool {
+	return s.failed
+}
+
+// Close closes the current file, if any.
+func (s *LineSource) Close() error {
+	s.closeFile()
+	s.next = len(s.names)
+	return nil
+}
+
+func (s *LineSource) fail(name string, err error) {
+	s.failed = true
+	if s.OnError != nil {
+		s.OnError(name, err)
+	}
+}
+
+func (s *LineSource) closeFile() {
+	s.LineEnding()
+	s.text = nil
+	if s.file != nil {
+		s.file.Close()
 	}
-	
-	return allLines, nil
+	s.file = nil
+	s.reader = nil
+	s.scanner = nil
 }
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+)
+
+// LineReader reads lines of any length. It is used like bufio.Scanner,
+// which gives up on lines longer than 64 KiB, but grows its buffer as far
+// as a line needs instead.
+//
+//	lr := NewLineReader(r, '\n')
+//	for lr.Scan() {
+//		line := lr.Text()
+//	}
+//	if err := lr.Err(); err != nil {
+type LineReader struct {
+	r     *bufio.Reader
+	delim byte
+	line  []byte
+	err   error
+}
+
+// NewLineReader returns a LineReader splitting r into lines terminated by
+// delim. The last line need not be terminated.
+func NewLineReader(r io.Reader, delim byte) *LineReader {
+	return &LineReader{r: bufio.NewReaderSize(r, 64*1024), delim: delim}
+}
+
+// Scan advances to the next line. It returns false at the end of the
+// input or on a read error, which Err then returns.
+func (l *LineReader) Scan() bool {
+	if l.err != nil {
+		return false
+	}
+
+	l.line = l.line[:0]
+	for {
+		chunk, err := l.r.ReadSlice(l.delim)
+		l.line = append(l.line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			l.err = err
+			return len(l.line) > 0
+		}
+		l.line = l.line[:len(l.line)-1]
+		return true
+	}
+}
+
+// Bytes returns the current line without its terminator. The slice is
+// overwritten by the next call to Scan.
+func (l *LineReader) Bytes() []byte {
+	return l.line
+}
+
+// Text returns the current line without its terminator.
+func (l *LineReader) Text() string {
+	return string(l.line)
+}
+
+// Err returns the first error other than io.EOF met by Scan.
+func (l *LineReader) Err() error {
+	if l.err == io.EOF {
+		return nil
+	}
+	return l.err
+}
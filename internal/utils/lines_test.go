@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	long := func(n int) string { return strings.Repeat("x", n) }
+	const buf = 64 * 1024 // the size LineReader reads ahead
+
+	tests := []struct {
+		name  string
+		in    string
+		delim byte
+		want  []string
+	}{
+		{"empty", "", '\n', nil},
+		{"one unterminated", "a", '\n', []string{"a"}},
+		{"one terminated", "a\n", '\n', []string{"a"}},
+		{"two", "a\nb", '\n', []string{"a", "b"}},
+		{"empty lines", "\n\na\n\n", '\n', []string{"", "", "a", ""}},
+		{"CR is kept", "a\r\nb\r\n", '\n', []string{"a\r", "b\r"}},
+		{"NUL delimited", "a\x00b\nc\x00", 0, []string{"a", "b\nc"}},
+		{"newline with NUL delimiter", "a\nb", 0, []string{"a\nb"}},
+		{"buffer size minus one", long(buf-1) + "\nb\n", '\n', []string{long(buf - 1), "b"}},
+		{"buffer size", long(buf) + "\nb\n", '\n', []string{long(buf), "b"}},
+		{"buffer size plus one", long(buf+1) + "\nb\n", '\n', []string{long(buf + 1), "b"}},
+		{"several buffers", "a\n" + long(3*buf+7) + "\n" + long(buf), '\n', []string{"a", long(3*buf + 7), long(buf)}},
+		{"5 MiB unterminated", long(5 << 20), '\n', []string{long(5 << 20)}},
+		{"5 MiB lines", long(5<<20) + "\n" + long(5<<20) + "y\n", '\n', []string{long(5 << 20), long(5<<20) + "y"}},
+	}
+	for _, tt := range tests {
+		// Reading a byte at a time makes every line cross reads.
+		for _, r := range []io.Reader{strings.NewReader(tt.in), &oneByteReader{tt.in}} {
+			lr := NewLineReader(r, tt.delim)
+			var got []string
+			for lr.Scan() {
+				got = append(got, lr.Text())
+			}
+			if err := lr.Err(); err != nil {
+				t.Errorf("%s: Err() = %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: got %d lines %.40q, want %d lines %.40q", tt.name, len(got), got, len(tt.want), tt.want)
+			}
+		}
+	}
+}
+
+func TestLineReaderError(t *testing.T) {
+	errRead := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("a\nb"), &failingReader{errRead})
+	lr := NewLineReader(r, '\n')
+
+	var got []string
+	for lr.Scan() {
+		got = append(got, lr.Text())
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if err := lr.Err(); err != errRead {
+		t.Errorf("Err() = %v, want %v", err, errRead)
+	}
+	if lr.Scan() {
+		t.Error("Scan() = true after an error")
+	}
+}
+
+type oneByteReader struct {
+	s string
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	if r.s == "" {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = r.s[0]
+	r.s = r.s[1:]
+	return 1, nil
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Unix compress writes LZW codes of 9 up to maxBits bits, LSB first, in
+// groups of eight codes. Whenever the code width changes or the table is
+// cleared, the rest of the current group is padding, which is why the
+// decoder below reads one group at a time rather than using compress/lzw.
+const (
+	compressInitBits = 9
+	compressClear    = 256 // resets the table in block mode
+	compressFirst    = 257 // first free code in block mode
+)
+
+var errCompressCorrupt = errors.New("compress: corrupt input")
+
+type compressReader struct {
+	r         io.Reader
+	maxBits   int
+	blockMode bool
+
+	// Code input: the current group of codes and the bit offset in it.
+	group      [16]byte
+	offset     int
+	size       int
+	nBits      int
+	maxCode    int
+	maxMaxCode int
+	clearFlag  bool
+
+	prefix  []uint16
+	suffix  []byte
+	freeEnt int
+	oldCode int
+	finChar byte
+
+	stack []byte // decoded bytes of the current code, in reverse
+	out   []byte // decoded bytes not yet returned by Read
+	err   error
+}
+
+// newCompressReader returns a reader decoding the Unix compress stream r,
+// which must start with the two magic bytes.
+func newCompressReader(r io.Reader) (io.Reader, error) {
+	var header [3]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("compress: reading header: %w", err)
+	}
+	maxBits := int(header[2] & 0x1f)
+	if maxBits < compressInitBits || maxBits > 16 {
+		return nil, fmt.Errorf("compress: unsupported code size %d", maxBits)
+	}
+
+	z := &compressReader{
+		r:          r,
+		maxBits:    maxBits,
+		blockMode:  header[2]&0x80 != 0,
+		nBits:      compressInitBits,
+		maxCode:    1<<compressInitBits - 1,
+		maxMaxCode: 1 << maxBits,
+		prefix:     make([]uint16, 1<<maxBits),
+		suffix:     make([]byte, 1<<maxBits),
+		freeEnt:    256,
+		oldCode:    -1,
+	}
+	if z.blockMode {
+		z.freeEnt = compressFirst
+	}
+	for i := 0; i < 256; i++ {
+		z.suffix[i] = byte(i)
+	}
+	return z, nil
+}
+
+// code returns the next code, or -1 at the end of the input.
+func (z *compressReader) code() (int, error) {
+	if z.clearFlag || z.offset >= z.size || z.freeEnt > z.maxCode {
+		// Start a new group, switching code width first if needed.
+		if z.freeEnt > z.maxCode {
+			z.nBits++
+			if z.nBits == z.maxBits {
+				z.maxCode = z.maxMaxCode
+			} else {
+				z.maxCode = 1<<z.nBits - 1
+			}
+		}
+		if z.clearFlag {
+			z.nBits = compressInitBits
+			z.maxCode = 1<<z.nBits - 1
+			z.clearFlag = false
+		}
+		n, err := io.ReadFull(z.r, z.group[:z.nBits])
+		if n == 0 {
+			if err == io.EOF {
+				return -1, nil
+			}
+			return -1, err
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return -1, err
+		}
+		z.offset = 0
+		// The last group may hold fewer codes; ignore trailing bits that
+		// cannot make up a whole one.
+		z.size = n*8 - (z.nBits - 1)
+	}
+
+	code := 0
+	for i := 0; i < z.nBits; i++ {
+		bit := z.offset + i
+		if z.group[bit/8]&(1<<(bit%8)) != 0 {
+			code |= 1 << i
+		}
+	}
+	z.offset += z.nBits
+	return code, nil
+}
+
+// decode decodes the next code into z.out.
+func (z *compressReader) decode() error {
+	code, err := z.code()
+	if err != nil || code < 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return err
+	}
+
+	if z.oldCode < 0 {
+		if code > 255 {
+			return errCompressCorrupt
+		}
+		z.oldCode, z.finChar = code, byte(code)
+		z.out = append(z.out, byte(code))
+		return nil
+	}
+
+	if code == compressClear && z.blockMode {
+		for i := range z.prefix {
+			z.prefix[i] = 0
+		}
+		z.clearFlag = true
+		z.freeEnt = compressFirst - 1
+		if code, err = z.code(); err != nil || code < 0 {
+			if err == nil {
+				err = io.EOF
+			}
+			return err
+		}
+	}
+
+	inCode := code
+	z.stack = z.stack[:0]
+	if code >= z.freeEnt {
+		// The KwKwK case: the code being defined is used at once.
+		if code > z.freeEnt {
+			return errCompressCorrupt
+		}
+		z.stack = append(z.stack, z.finChar)
+		code = z.oldCode
+	}
+	for code >= 256 {
+		z.stack = append(z.stack, z.suffix[code])
+		code = int(z.prefix[code])
+	}
+	z.finChar = z.suffix[code]
+	z.stack = append(z.stack, z.finChar)
+	for i := len(z.stack) - 1; i >= 0; i-- {
+		z.out = append(z.out, z.stack[i])
+	}
+
+	if z.freeEnt < z.maxMaxCode {
+		z.prefix[z.freeEnt] = uint16(z.oldCode)
+		z.suffix[z.freeEnt] = z.finChar
+		z.freeEnt++
+	}
+	z.oldCode = inCode
+	return nil
+}
+
+func (z *compressReader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 && z.err == nil {
+		z.out = z.out[:0]
+		z.err = z.decode()
+	}
+	if len(z.out) == 0 {
+		return 0, z.err
+	}
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+	return n, nil
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// LineEnding is the line terminator style of a text.
+type LineEnding int
+
+const (
+	LineEndingUnknown LineEnding = iota // no line terminator seen yet
+	LineEndingLF
+	LineEndingCRLF
+)
+
+// TextReader decodes text files as they are commonly found on Windows. It
+// drops a UTF-8 byte order mark, converts UTF-16 with a byte order mark to
+// UTF-8 and, unless told to keep them, removes the CR of CRLF line endings
+// so that lines read from it never end in "\r". A CR that is not followed
+// by LF is kept.
+type TextReader struct {
+	r      *bufio.Reader
+	order  binary.ByteOrder // nil for UTF-8
+	keepCR bool
+
+	ending    LineEnding
+	pendingCR bool   // a CR at the end of the last chunk
+	surrogate rune   // a high surrogate at the end of the last chunk
+	odd       []byte // a UTF-16 byte at the end of the last chunk
+
+	buf []byte
+	out []byte
+	err error
+}
+
+// NewTextReader returns a TextReader decoding r. It reads the first chunk
+// of r straight away, so that LineEnding can usually tell the style before
+// anything has been read from the TextReader.
+func NewTextReader(r io.Reader, keepCR bool) *TextReader {
+	t := &TextReader{r: bufio.NewReader(r), keepCR: keepCR, buf: make([]byte, 32*1024)}
+
+	// Only look further than the first byte when it can start a BOM, so
+	// that a short first line on a terminal is not held back.
+	if b, _ := t.r.Peek(1); len(b) == 1 {
+		switch b[0] {
+		case 0xef:
+			if b, _ := t.r.Peek(3); len(b) == 3 && b[1] == 0xbb && b[2] == 0xbf {
+				t.r.Discard(3)
+			}
+		case 0xff:
+			if b, _ := t.r.Peek(2); len(b) == 2 && b[1] == 0xfe {
+				t.order = binary.LittleEndian
+				t.r.Discard(2)
+			}
+		case 0xfe:
+			if b, _ := t.r.Peek(2); len(b) == 2 && b[1] == 0xff {
+				t.order = binary.BigEndian
+				t.r.Discard(2)
+			}
+		}
+	}
+
+	t.fill()
+	return t
+}
+
+// LineEnding returns the terminator of the first line read so far.
+func (t *TextReader) LineEnding() LineEnding {
+	return t.ending
+}
+
+func (t *TextReader) Read(p []byte) (int, error) {
+	for len(t.out) == 0 && t.err == nil {
+		t.fill()
+	}
+	if len(t.out) == 0 {
+		return 0, t.err
+	}
+	n := copy(p, t.out)
+	t.out = t.out[n:]
+	return n, nil
+}
+
+// fill decodes the next chunk of input into t.out.
+func (t *TextReader) fill() {
+	if t.err != nil {
+		return
+	}
+	n, err := t.r.Read(t.buf)
+	chunk := t.buf[:n]
+	if t.order != nil {
+		chunk = t.decodeUTF16(chunk, err != nil)
+	}
+
+	out := t.out[:0]
+	for _, c := range chunk {
+		if t.pendingCR {
+			t.pendingCR = false
+			if c == '\n' {
+				if t.ending == LineEndingUnknown {
+					t.ending = LineEndingCRLF
+				}
+				if t.keepCR {
+					out = append(out, '\r')
+				}
+				out = append(out, '\n')
+				continue
+			}
+			out = append(out, '\r')
+		}
+		switch c {
+		case '\r':
+			t.pendingCR = true
+			continue
+		case '\n':
+			if t.ending == LineEndingUnknown {
+				t.ending = LineEndingLF
+			}
+		}
+		out = append(out, c)
+	}
+
+	if err != nil {
+		if t.pendingCR {
+			out = append(out, '\r')
+			t.pendingCR = false
+		}
+		t.err = err
+	}
+	t.out = out
+}
+
+// decodeUTF16 converts a chunk of UTF-16 to UTF-8, carrying an odd byte or
+// a high surrogate over to the next chunk. At the end of the input, such
+// leftovers are replaced by U+FFFD.
+func (t *TextReader) decodeUTF16(chunk []byte, last bool) []byte {
+	if len(t.odd) > 0 {
+		chunk = append(t.odd, chunk...)
+		t.odd = nil
+	}
+	if len(chunk)%2 == 1 {
+		t.odd = []byte{chunk[len(chunk)-1]}
+		chunk = chunk[:len(chunk)-1]
+	}
+
+	var out []byte
+	for i := 0; i+1 < len(chunk); i += 2 {
+		r := rune(t.order.Uint16(chunk[i:]))
+		if t.surrogate != 0 {
+			high := t.surrogate
+			t.surrogate = 0
+			if dec := utf16.DecodeRune(high, r); dec != utf8.RuneError {
+				out = utf8.AppendRune(out, dec)
+				continue
+			}
+			out = utf8.AppendRune(out, utf8.RuneError)
+		}
+		if utf16.IsSurrogate(r) && r < 0xdc00 {
+			t.surrogate = r
+			continue
+		}
+		if utf16.IsSurrogate(r) {
+			r = utf8.RuneError
+		}
+		out = utf8.AppendRune(out, r)
+	}
+
+	if last && (t.surrogate != 0 || len(t.odd) > 0) {
+		out = utf8.AppendRune(out, utf8.RuneError)
+		t.surrogate, t.odd = 0, nil
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WalkOptions configures a recursive search for files, as done by
+// grep -r. Patterns are matched with MatchGlob against base names.
+type WalkOptions struct {
+	// FollowLinks follows symbolic links met while walking. Links given
+	// as the root are always followed.
+	FollowLinks bool
+	// Include, when not empty, limits the search to files matching one of
+	// its patterns. Exclude skips files matching one of its patterns, and
+	// ExcludeDir skips directories matching one of its patterns.
+	Include    []string
+	Exclude    []string
+	ExcludeDir []string
+	// OnError is called for every file or directory that cannot be read.
+	OnError func(path string, err error)
+}
+
+// Selects reports whether the file at path passes Include and Exclude.
+func (o *WalkOptions) Selects(path string) bool {
+	name := filepath.Base(path)
+	if matchAny(o.Exclude, name) {
+		return false
+	}
+	return len(o.Include) == 0 || matchAny(o.Include, name)
+}
+
+// SelectsDir reports whether the directory at path passes ExcludeDir.
+func (o *WalkOptions) SelectsDir(path string) bool {
+	name := filepath.Base(path)
+	return name == "." || name == ".." || !matchAny(o.ExcludeDir, name)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if MatchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk calls fn with root, if it is a selected file, or with every
+// selected regular file under root, if it is a directory. Directory
+// entries are visited in lexical order. A root of "" stands for the
+// current directory, with paths given relative to it, like "a/b" rather
+// than "./a/b".
+func (o *WalkOptions) Walk(root string, fn func(path string)) {
+	dir := root
+	if dir == "" {
+		dir = "."
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		o.fail(dir, err)
+		return
+	}
+	if !info.IsDir() {
+		if o.Selects(root) {
+			fn(root)
+		}
+		return
+	}
+	if o.SelectsDir(dir) {
+		o.walkDir(root, []fs.FileInfo{info}, fn)
+	}
+}
+
+// walkDir walks the directory dir, the last of the directories in
+// parents, which are those it was reached through.
+func (o *WalkOptions) walkDir(dir string, parents []fs.FileInfo, fn func(path string)) {
+	name := dir
+	if name == "" {
+		name = "."
+	}
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		o.fail(name, err)
+	}
+
+	for _, entry := range entries {
+		path := entry.Name()
+		if dir != "" {
+			path = joinPath(dir, path)
+		}
+
+		mode := entry.Type()
+		var info fs.FileInfo
+		if mode&fs.ModeSymlink != 0 {
+			if !o.FollowLinks {
+				continue
+			}
+			if info, err = os.Stat(path); err != nil {
+				o.fail(path, err)
+				continue
+			}
+			mode = info.Mode().Type()
+		}
+
+		switch {
+		case mode.IsDir():
+			if !o.SelectsDir(path) {
+				continue
+			}
+			if info == nil {
+				if info, err = entry.Info(); err != nil {
+					o.fail(path, err)
+					continue
+				}
+			}
+			if o.FollowLinks && isLoop(parents, info) {
+				o.fail(path, fmt.Errorf("%s: recursive directory loop", path))
+				continue
+			}
+			o.walkDir(path, append(parents, info), fn)
+		case mode.IsRegular():
+			if o.Selects(path) {
+				fn(path)
+			}
+		}
+	}
+}
+
+// isLoop reports whether the directory info is one of parents.
+func isLoop(parents []fs.FileInfo, info fs.FileInfo) bool {
+	for _, p := range parents {
+		if os.SameFile(p, info) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *WalkOptions) fail(path string, err error) {
+	if o.OnError != nil {
+		o.OnError(path, err)
+	}
+}
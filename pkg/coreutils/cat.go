@@ -0,0 +1,28 @@
+package coreutils
+
+import (
+	"context"
+	"io"
+)
+
+// Cat copies r to w unchanged.
+func Cat(ctx context.Context, r io.Reader, w io.Writer) error {
+	buf := make([]byte, 32*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
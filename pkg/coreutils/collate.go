@@ -0,0 +1,64 @@
+package coreutils
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// parseLocale turns a locale name into a language tag. Both BCP 47 tags
+// like "de-DE" and POSIX names like "de_DE.UTF-8" are accepted. The names
+// "", "C" and "POSIX" stand for byte order and give ok == false.
+func parseLocale(name string) (tag language.Tag, ok bool, err error) {
+	name, _, _ = strings.Cut(name, "@")
+	name, _, _ = strings.Cut(name, ".")
+	switch name {
+	case "", "C", "POSIX":
+		return language.Und, false, nil
+	}
+	tag, err = language.Parse(strings.ReplaceAll(name, "_", "-"))
+	if err != nil {
+		return language.Und, false, fmt.Errorf("invalid locale %q", name)
+	}
+	return tag, true, nil
+}
+
+// CheckLocale reports whether name is a locale that SortOptions.Locale and
+// UniqOptions.Locale accept.
+func CheckLocale(name string) error {
+	_, _, err := parseLocale(name)
+	return err
+}
+
+// collation turns text into keys that compare, byte by byte, in the order
+// of the Unicode Collation Algorithm as tailored for a locale. Canonically
+// equivalent text, such as the NFC and NFD forms of "é", gets equal keys.
+// A collation is not safe for concurrent use.
+type collation struct {
+	c   *collate.Collator
+	buf collate.Buffer
+}
+
+// newCollation returns the collation for the named locale, or nil when the
+// locale stands for byte order or is invalid. With foldCase, text that
+// differs only in case collates equal.
+func newCollation(locale string, foldCase bool) *collation {
+	tag, ok, err := parseLocale(locale)
+	if !ok || err != nil {
+		return nil
+	}
+	var opts []collate.Option
+	if foldCase {
+		opts = append(opts, collate.IgnoreCase)
+	}
+	return &collation{c: collate.New(tag, opts...)}
+}
+
+// key returns the collation key of s.
+func (c *collation) key(s string) string {
+	key := string(c.c.KeyFromString(&c.buf, s))
+	c.buf.Reset()
+	return key
+}
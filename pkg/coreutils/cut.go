@@ -0,0 +1,109 @@
+package coreutils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CutOptions configures Cut. Exactly one of Fields and Characters must be
+// set; both are lists such as "1,3" or "1-5,7".
+type CutOptions struct {
+	Fields     string // fields to select
+	Characters string // character positions to select
+	// Delimiter separates fields. It defaults to a tab.
+	Delimiter string
+	// ZeroTerminated makes lines end with NUL instead of newline.
+	ZeroTerminated bool
+}
+
+// Cut writes the selected fields or characters of each line of r to w.
+func Cut(ctx context.Context, r io.Reader, w io.Writer, opts CutOptions) error {
+	fields, characters := opts.Fields, opts.Characters
+	if (fields == "" && characters == "") || (fields != "" && characters != "") {
+		return fmt.Errorf("specify either --fields or --characters")
+	}
+
+	delimiter := opts.Delimiter
+	if delimiter == "" {
+		delimiter = "\t"
+	}
+
+	var indices []int
+	var err error
+	if fields != "" {
+		indices, err = parseRanges(fields)
+		if err != nil {
+			return fmt.Errorf("invalid field list: %v", err)
+		}
+	} else {
+		indices, err = parseRanges(characters)
+		if err != nil {
+			return fmt.Errorf("invalid character list: %v", err)
+		}
+	}
+
+	end := lineDelim(opts.ZeroTerminated)
+	return eachLine(ctx, r, end, func(line string) error {
+		if fields != "" {
+			return writeLine(w, selectFields(line, delimiter, indices), end)
+		}
+		return writeLine(w, selectCharacters(line, indices), end)
+	})
+}
+
+func parseRanges(input string) ([]int, error) {
+	var indices []int
+	parts := strings.Split(input, ",")
+	for _, part := range parts {
+		if strings.Contains(part, "-") {
+			rangeParts := strings.Split(part, "-")
+			start, err := strconv.Atoi(rangeParts[0])
+			if err != nil {
+				return nil, err
+			}
+			end := start
+			if len(rangeParts) > 1 {
+				end, err = strconv.Atoi(rangeParts[1])
+				if err != nil {
+					return nil, err
+				}
+			}
+			for i := start; i <= end; i++ {
+				indices = append(indices, i)
+			}
+		} else {
+			idx, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, err
+			}
+			indices = append(indices, idx)
+		}
+	}
+	return indices, nil
+}
+
+func selectFields(line, delimiter string, fieldIndices []int) string {
+	parts := strings.Split(line, delimiter)
+
+	var selectedFields []string
+	for _, idx := range fieldIndices {
+		if idx > 0 && idx <= len(parts) {
+			selectedFields = append(selectedFields, parts[idx-1]) // 1-based index
+		}
+	}
+	return strings.Join(selectedFields, delimiter)
+}
+
+func selectCharacters(line string, charIndices []int) string {
+	runes := []rune(line)
+	var selectedChars []rune
+	for _, idx := range charIndices {
+		if idx > 0 && idx <= len(runes) {
+			selectedChars = append(selectedChars, runes[idx-1]) // 1-based index
+		}
+	}
+	return string(selectedChars)
+}
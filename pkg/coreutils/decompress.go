@@ -0,0 +1,15 @@
+package coreutils
+
+import (
+	"io"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+)
+
+// Decompress returns a reader that decodes r if it is a gzip, bzip2, zlib
+// or Unix compress (.Z) stream, recognized by its magic number, and that
+// returns r's content unchanged otherwise. Wrap a reader with it before
+// passing it to any of the other functions to get zcat/zgrep behavior.
+func Decompress(r io.Reader) (io.Reader, error) {
+	return utils.Decompress(r)
+}
@@ -0,0 +1,13 @@
+// Package coreutils implements the bashutils text utilities as plain Go
+// functions over io.Reader and io.Writer, so they can be embedded in other
+// programs without going through a command line. The bashutils commands
+// are thin wrappers around this package.
+//
+// Each utility takes a typed options struct mirroring the command's flags.
+// Functions that read input check ctx between lines and return ctx.Err()
+// once it is cancelled.
+//
+// Lines end at "\n" and are otherwise taken as they are. Wrap input with
+// Decompress or DecodeText to read compressed files, or text with CRLF
+// line endings, byte order marks or UTF-16, the way the commands do.
+package coreutils
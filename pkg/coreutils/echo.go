@@ -0,0 +1,36 @@
+package coreutils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+)
+
+// EchoOptions configures Echo.
+type EchoOptions struct {
+	NoNewline bool // do not write the trailing newline
+	Escapes   bool // interpret \n and \t
+	ExpandEnv bool // expand $VAR and %VAR%
+}
+
+// Echo writes args separated by spaces to w.
+func Echo(w io.Writer, args []string, opts EchoOptions) error {
+	text := strings.Join(args, " ")
+
+	if opts.ExpandEnv {
+		text = utils.ExpandEnvironmentVariables(text)
+	}
+
+	if opts.Escapes {
+		text = strings.ReplaceAll(text, "\\n", "\n")
+		text = strings.ReplaceAll(text, "\\t", "\t")
+	}
+
+	if !opts.NoNewline {
+		text += "\n"
+	}
+	_, err := fmt.Fprint(w, text)
+	return err
+}
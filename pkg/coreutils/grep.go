@@ -0,0 +1,343 @@
+package coreutils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// GrepSyntax is the syntax of grep patterns.
+type GrepSyntax int
+
+const (
+	GrepRE2      GrepSyntax = iota // Go (RE2) regular expressions
+	GrepBasic                      // POSIX basic regular expressions (BRE)
+	GrepExtended                   // POSIX extended regular expressions (ERE)
+	GrepFixed                      // fixed strings
+)
+
+// GrepOptions configures Grep.
+type GrepOptions struct {
+	// Pattern is what lines are matched against. A newline in it separates
+	// patterns, any of which can match.
+	Pattern string
+	// Patterns, when not nil, is used instead of Pattern, and can be empty
+	// so that nothing matches.
+	Patterns []string
+	Syntax   GrepSyntax // syntax of the patterns
+	// WordRegexp only counts matches that are neither preceded nor
+	// followed by a letter, digit or underscore. LineRegexp only counts
+	// matches of the whole line.
+	WordRegexp  bool
+	LineRegexp  bool
+	IgnoreCase  bool // ignore case distinctions
+	InvertMatch bool // select non-matching lines
+	LineNumber  bool // prefix each line with its line number
+	ByteOffset  bool // prefix each line with the offset of its first byte
+	// WithFilename prefixes each line with the name of its file.
+	WithFilename bool
+	// OnlyMatching prints each match on a line of its own instead of the
+	// whole line. Empty matches are not printed.
+	OnlyMatching bool
+	// Count prints, for each file, only the number of selected lines.
+	Count bool
+	// FilesWithMatches prints only the names of files with a selected
+	// line, and FilesWithoutMatch those of files without one.
+	FilesWithMatches  bool
+	FilesWithoutMatch bool
+	// Quiet prints nothing; the number of selected lines still tells
+	// whether there was a match. Reading stops at the first one.
+	Quiet bool
+	// MaxCount stops reading a file after that many selected lines, and
+	// their trailing context. Zero means no limit.
+	MaxCount int
+	// BeforeContext and AfterContext print that many lines before and
+	// after each selected line. Their prefixes end with '-' rather than
+	// ':', and a "--" line separates groups of lines that are not
+	// adjacent. Context is only printed along with whole lines.
+	BeforeContext int
+	AfterContext  int
+	// Null follows file names with NUL instead of the usual separator,
+	// so that they can contain any character.
+	Null bool
+	// ZeroTerminated makes lines end with NUL instead of newline.
+	ZeroTerminated bool
+}
+
+// Grepper selects lines matching compiled patterns. It remembers whether
+// it has printed anything, so that context groups from several files are
+// separated, and is not safe for concurrent use.
+type Grepper struct {
+	opts    GrepOptions
+	m       grepMatcher
+	printed bool // some line was printed with context
+}
+
+// NewGrepper compiles the patterns of opts. The error describes an invalid
+// pattern. Fixed strings are searched for all at once, without a regular
+// expression, unless they are to match case-insensitively, as words or as
+// whole lines.
+func NewGrepper(opts GrepOptions) (*Grepper, error) {
+	patterns := opts.Patterns
+	if patterns == nil {
+		patterns = []string{opts.Pattern}
+	}
+	var split []string
+	for _, p := range patterns {
+		split = append(split, strings.Split(p, "\n")...)
+	}
+
+	if opts.Syntax == GrepFixed && !opts.IgnoreCase && !opts.WordRegexp && !opts.LineRegexp {
+		return &Grepper{opts: opts, m: newFixedMatcher(split)}, nil
+	}
+	m, err := newRegexpMatcher(split, opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %v", err)
+	}
+	return &Grepper{opts: opts, m: m}, nil
+}
+
+// Grep writes the selected lines of r to w and returns how many there were.
+func (g *Grepper) Grep(ctx context.Context, r io.Reader, w io.Writer) (int, error) {
+	return g.GrepFile(ctx, r, w, "(standard input)")
+}
+
+// GrepFile is like Grep, for a file called name. The name is printed with
+// WithFilename, FilesWithMatches and FilesWithoutMatch.
+func (g *Grepper) GrepFile(ctx context.Context, r io.Reader, w io.Writer, name string) (int, error) {
+	o := &g.opts
+	f := &grepFile{g: g, w: w, name: name, delim: lineDelim(o.ZeroTerminated)}
+	if g.context() {
+		f.before = newGrepRing(o.BeforeContext)
+	}
+	stopAtFirst := o.Quiet || o.FilesWithMatches || o.FilesWithoutMatch
+	selected := 0
+	maxed := false // MaxCount lines were selected; only trailing context is left
+	lineNum := 0
+	offset := 0
+	err := eachLine(ctx, r, f.delim, func(text string) error {
+		lineNum++
+		line := grepLine{text: text, num: lineNum, offset: offset}
+		offset += len(text) + 1
+
+		if maxed {
+			if f.after == 0 {
+				return errStop
+			}
+			return f.context(line)
+		}
+		if g.m.match(text) == o.InvertMatch {
+			return f.context(line)
+		}
+		selected++
+		if stopAtFirst {
+			return errStop
+		}
+
+		if !o.Count {
+			if err := f.selected(line); err != nil {
+				return err
+			}
+		}
+		if o.MaxCount > 0 && selected >= o.MaxCount {
+			maxed = true
+			if f.after == 0 {
+				return errStop
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return selected, err
+	}
+
+	switch {
+	case o.Quiet:
+	case o.FilesWithMatches || o.FilesWithoutMatch:
+		if (selected > 0) == o.FilesWithMatches {
+			err = g.writeName(w, name)
+		}
+	case o.Count:
+		count := strconv.Itoa(selected)
+		if o.WithFilename {
+			count = name + g.nameSep(':') + count
+		}
+		_, err = io.WriteString(w, count+"\n")
+	}
+	return selected, err
+}
+
+// context reports whether lines around the selected ones are printed.
+func (g *Grepper) context() bool {
+	o := &g.opts
+	if o.OnlyMatching || o.Count || o.Quiet || o.FilesWithMatches || o.FilesWithoutMatch {
+		return false
+	}
+	return o.BeforeContext > 0 || o.AfterContext > 0
+}
+
+// grepLine is a line of input with its place in the file.
+type grepLine struct {
+	text   string
+	num    int // line number, from 1
+	offset int // offset of the first byte
+}
+
+// grepFile writes the output for the lines of one file.
+type grepFile struct {
+	g     *Grepper
+	w     io.Writer
+	name  string
+	delim byte
+
+	before *grepRing // lines that may come before a selected line
+	after  int       // lines still to print after the last selected line
+	last   int       // number of the last line printed, 0 if none
+}
+
+// selected writes a selected line, preceded by the lines before it.
+func (f *grepFile) selected(line grepLine) error {
+	if f.before != nil {
+		err := f.before.drain(func(l grepLine) error {
+			return f.write(l, '-')
+		})
+		if err != nil {
+			return err
+		}
+	}
+	f.after = f.g.opts.AfterContext
+	if !f.g.opts.OnlyMatching {
+		return f.write(line, ':')
+	}
+	if f.g.opts.InvertMatch {
+		return nil // the line has no matches to show
+	}
+	for _, m := range f.g.m.findAll(line.text) {
+		if m[0] == m[1] {
+			continue
+		}
+		match := grepLine{text: line.text[m[0]:m[1]], num: line.num, offset: line.offset + m[0]}
+		if err := f.write(match, ':'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// context deals with a line that is not selected: it is printed if it
+// closely follows a selected line, and otherwise kept in case one comes
+// soon after it.
+func (f *grepFile) context(line grepLine) error {
+	if f.before == nil {
+		return nil
+	}
+	if f.after > 0 {
+		f.after--
+		return f.write(line, '-')
+	}
+	f.before.push(line)
+	return nil
+}
+
+// write writes line with its prefix, whose parts end with sep. With
+// context, a "--" line goes before a line that does not follow the last
+// one printed.
+func (f *grepFile) write(line grepLine, sep byte) error {
+	if f.g.context() {
+		if f.g.printed && (f.last == 0 || line.num != f.last+1) {
+			if err := writeLine(f.w, "--", f.delim); err != nil {
+				return err
+			}
+		}
+		f.g.printed = true
+		f.last = line.num
+	}
+	return writeLine(f.w, f.g.prefix(f.name, line.num, line.offset, sep)+line.text, f.delim)
+}
+
+// grepRing holds the last few lines read, oldest first, dropping the
+// oldest when a line is pushed while it is full.
+type grepRing struct {
+	lines []grepLine
+	start int // index of the oldest line
+	n     int // number of lines held
+}
+
+func newGrepRing(size int) *grepRing {
+	return &grepRing{lines: make([]grepLine, size)}
+}
+
+func (r *grepRing) push(line grepLine) {
+	switch {
+	case len(r.lines) == 0:
+	case r.n < len(r.lines):
+		r.lines[(r.start+r.n)%len(r.lines)] = line
+		r.n++
+	default:
+		r.lines[r.start] = line
+		r.start = (r.start + 1) % len(r.lines)
+	}
+}
+
+// drain calls fn with each line held, oldest first, and empties r.
+func (r *grepRing) drain(fn func(line grepLine) error) error {
+	for ; r.n > 0; r.n-- {
+		line := r.lines[r.start]
+		r.lines[r.start] = grepLine{}
+		r.start = (r.start + 1) % len(r.lines)
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefix returns what goes before a line of output: the file name, line
+// number and byte offset as the options ask, each followed by sep.
+func (g *Grepper) prefix(name string, lineNum, offset int, sep byte) string {
+	var b strings.Builder
+	if g.opts.WithFilename {
+		b.WriteString(name)
+		b.WriteString(g.nameSep(sep))
+	}
+	if g.opts.LineNumber {
+		b.WriteString(strconv.Itoa(lineNum))
+		b.WriteByte(sep)
+	}
+	if g.opts.ByteOffset {
+		b.WriteString(strconv.Itoa(offset))
+		b.WriteByte(sep)
+	}
+	return b.String()
+}
+
+// nameSep returns what follows a file name in place of sep.
+func (g *Grepper) nameSep(sep byte) string {
+	if g.opts.Null {
+		return "\x00"
+	}
+	return string(sep)
+}
+
+// writeName writes name on a line of its own, or followed by NUL with
+// Null.
+func (g *Grepper) writeName(w io.Writer, name string) error {
+	end := "\n"
+	if g.opts.Null {
+		end = "\x00"
+	}
+	_, err := io.WriteString(w, name+end)
+	return err
+}
+
+// Grep writes the lines of r selected by opts to w and returns how many
+// there were.
+func Grep(ctx context.Context, r io.Reader, w io.Writer, opts GrepOptions) (int, error) {
+	g, err := NewGrepper(opts)
+	if err != nil {
+		return 0, err
+	}
+	return g.Grep(ctx, r, w)
+}
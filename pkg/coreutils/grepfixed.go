@@ -0,0 +1,130 @@
+package coreutils
+
+// fixedMatcher finds any of a set of strings with the Aho-Corasick
+// algorithm, in a single pass over a line however many strings there are.
+type fixedMatcher struct {
+	nodes []fixedNode // a trie of the strings; nodes[0] is the root
+	empty bool        // one of the strings is empty, so every line matches
+}
+
+// fixedNode is a node of the trie of a fixedMatcher, standing for the
+// prefix of one or more strings that leads to it.
+type fixedNode struct {
+	next  map[byte]int
+	depth int  // length of the prefix
+	final bool // the prefix is one of the strings
+	// fail is the node for the longest proper suffix of the prefix that is
+	// in the trie, and out the nearest node along fail links that is final,
+	// or 0 if there is none.
+	fail int
+	out  int
+}
+
+func newFixedMatcher(patterns []string) *fixedMatcher {
+	m := &fixedMatcher{nodes: []fixedNode{{}}}
+	for _, p := range patterns {
+		if p == "" {
+			m.empty = true
+			continue
+		}
+		n := 0
+		for i := 0; i < len(p); i++ {
+			child, ok := m.nodes[n].next[p[i]]
+			if !ok {
+				child = len(m.nodes)
+				m.nodes = append(m.nodes, fixedNode{depth: i + 1})
+				if m.nodes[n].next == nil {
+					m.nodes[n].next = make(map[byte]int)
+				}
+				m.nodes[n].next[p[i]] = child
+			}
+			n = child
+		}
+		m.nodes[n].final = true
+	}
+
+	// Set the fail and out links breadth first, so that those of the
+	// shorter prefixes they point to are already set.
+	queue := []int{0}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for c, child := range m.nodes[n].next {
+			queue = append(queue, child)
+			if n == 0 {
+				continue
+			}
+			fail := m.step(m.nodes[n].fail, c)
+			m.nodes[child].fail = fail
+			if m.nodes[fail].final {
+				m.nodes[child].out = fail
+			} else {
+				m.nodes[child].out = m.nodes[fail].out
+			}
+		}
+	}
+	return m
+}
+
+// step returns the node reached from node n by the byte c.
+func (m *fixedMatcher) step(n int, c byte) int {
+	for {
+		if next, ok := m.nodes[n].next[c]; ok {
+			return next
+		}
+		if n == 0 {
+			return 0
+		}
+		n = m.nodes[n].fail
+	}
+}
+
+// firstOut returns the deepest final node among n and those it fails to,
+// or 0 if there is none.
+func (m *fixedMatcher) firstOut(n int) int {
+	if m.nodes[n].final {
+		return n
+	}
+	return m.nodes[n].out
+}
+
+func (m *fixedMatcher) match(line string) bool {
+	if m.empty {
+		return true
+	}
+	n := 0
+	for i := 0; i < len(line); i++ {
+		n = m.step(n, line[i])
+		if m.firstOut(n) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// findAll returns the leftmost-longest non-empty matches, as POSIX
+// regular expressions would.
+func (m *fixedMatcher) findAll(line string) [][]int {
+	var matches [][]int
+	var best []int // the leftmost-longest match found so far
+	n := 0
+	for i := 0; i < len(line); i++ {
+		n = m.step(n, line[i])
+		for out := m.firstOut(n); out != 0; out = m.nodes[out].out {
+			start := i + 1 - m.nodes[out].depth
+			if best == nil || start < best[0] || start == best[0] && i+1 > best[1] {
+				best = []int{start, i + 1}
+			}
+		}
+		// No match still to come can start where best does once the
+		// prefix being followed starts after it, so best is final. The
+		// search goes on from its end.
+		if best != nil && (i+1 == len(line) || i+1-m.nodes[n].depth > best[0]) {
+			matches = append(matches, best)
+			i = best[1] - 1
+			n = 0
+			best = nil
+		}
+	}
+	return matches
+}
@@ -0,0 +1,133 @@
+package coreutils
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// grepMatcher finds the matches of grep's patterns in a line.
+type grepMatcher interface {
+	// match reports whether line holds a match.
+	match(line string) bool
+	// findAll returns the start and end of the successive matches in
+	// line that do not overlap.
+	findAll(line string) [][]int
+}
+
+// regexpMatcher matches a regular expression.
+type regexpMatcher struct {
+	re *regexp.Regexp
+	// whole is set for WordRegexp. It matches the pattern against a whole
+	// string, while re also matches the character before the match, which
+	// is its first group.
+	whole *regexp.Regexp
+}
+
+// newRegexpMatcher compiles the patterns in the syntax of opts into a
+// regular expression matching any of them.
+func newRegexpMatcher(patterns []string, opts GrepOptions) (*regexpMatcher, error) {
+	parts := make([]string, len(patterns))
+	for i, p := range patterns {
+		switch opts.Syntax {
+		case GrepFixed:
+			p = regexp.QuoteMeta(p)
+		case GrepBasic, GrepExtended:
+			var err error
+			if p, err = posixToRE2(p, opts.Syntax == GrepBasic); err != nil {
+				return nil, err
+			}
+		}
+		// Each pattern is checked alone, as once joined one could swallow
+		// the next.
+		if _, err := syntax.Parse(p, syntax.Perl); err != nil {
+			return nil, err
+		}
+		parts[i] = "(?:" + p + ")"
+	}
+	expr := strings.Join(parts, "|")
+	if len(parts) == 0 {
+		expr = `[^\x00-\x{10FFFF}]` // matches nothing
+	}
+
+	flags := ""
+	if opts.IgnoreCase {
+		flags = "(?i)"
+	}
+	m := &regexpMatcher{}
+	switch {
+	case opts.LineRegexp:
+		expr = "^(?:" + expr + ")$"
+	case opts.WordRegexp:
+		whole, err := regexp.Compile(flags + "^(?:" + expr + ")$")
+		if err != nil {
+			return nil, err
+		}
+		m.whole = whole
+		expr = `(?:^|[^\pL\pN_])(` + expr + ")"
+	}
+	re, err := regexp.Compile(flags + expr)
+	if err != nil {
+		return nil, err
+	}
+	m.re = re
+
+	// POSIX regular expressions prefer the longest of the matches that
+	// start first.
+	if opts.Syntax != GrepRE2 {
+		m.re.Longest()
+		if m.whole != nil {
+			m.whole.Longest()
+		}
+	}
+	return m, nil
+}
+
+func (m *regexpMatcher) match(line string) bool {
+	if m.whole == nil {
+		return m.re.MatchString(line)
+	}
+	return m.re.MatchString(line) && len(m.words(line, 1)) > 0
+}
+
+func (m *regexpMatcher) findAll(line string) [][]int {
+	if m.whole == nil {
+		return m.re.FindAllStringIndex(line, -1)
+	}
+	return m.words(line, -1)
+}
+
+// words returns up to n matches, or all of them if n < 0, that are neither
+// preceded nor followed by a word character. A match followed by one is
+// shortened to a match that is not, if there is one.
+func (m *regexpMatcher) words(line string, n int) [][]int {
+	var words [][]int
+	for _, loc := range m.re.FindAllStringSubmatchIndex(line, -1) {
+		start, end := loc[2], loc[3]
+		for ; end >= start; end-- {
+			if !isWordCharAt(line, end) && (end == loc[3] || m.whole.MatchString(line[start:end])) {
+				break
+			}
+		}
+		if end < start {
+			continue
+		}
+		words = append(words, []int{start, end})
+		if len(words) == n {
+			break
+		}
+	}
+	return words
+}
+
+// isWordCharAt reports whether line has a letter, digit or underscore at
+// byte offset i.
+func isWordCharAt(line string, i int) bool {
+	if i >= len(line) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(line[i:])
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,319 @@
+package coreutils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// posixClasses are the character class names allowed in bracket
+// expressions, all of which RE2 knows too.
+var posixClasses = map[string]bool{
+	"alnum": true, "alpha": true, "blank": true, "cntrl": true,
+	"digit": true, "graph": true, "lower": true, "print": true,
+	"punct": true, "space": true, "upper": true, "xdigit": true,
+}
+
+// posixToRE2 translates a POSIX regular expression into RE2 syntax. The
+// expression is basic (BRE) if basic is set, and extended (ERE) otherwise.
+// The GNU extensions are understood: \+, \? and \| in BREs, \< and \> for
+// word boundaries, and \w, \W, \s, \S, \b and \B. Back-references have no
+// RE2 equivalent and are an error.
+func posixToRE2(expr string, basic bool) (string, error) {
+	t := &posixTranslator{src: expr, basic: basic, atStart: true, atom: -1}
+	for t.pos < len(t.src) {
+		if err := t.next(); err != nil {
+			return "", err
+		}
+	}
+	return t.out.String(), nil
+}
+
+// posixTranslator holds the state of posixToRE2.
+type posixTranslator struct {
+	src   string
+	pos   int
+	basic bool
+	out   strings.Builder
+
+	atStart  bool  // at the start of the expression or of a group or branch
+	atom     int   // where the last atom starts in out, or -1 if none
+	repeated bool  // the last atom already has a repetition operator
+	groups   []int // where the groups still open start in out
+}
+
+// next translates the next token of the source.
+func (t *posixTranslator) next() error {
+	c, size := utf8.DecodeRuneInString(t.src[t.pos:])
+	raw := t.src[t.pos : t.pos+size]
+	t.pos += size
+
+	switch c {
+	case '\\':
+		return t.escape()
+	case '[':
+		class, err := t.bracket()
+		if err != nil {
+			return err
+		}
+		t.emitAtom(class)
+	case '.':
+		t.emitAtom(".")
+	case '*':
+		t.repeat("*")
+	case '^':
+		if t.basic && !t.atStart {
+			t.emitAtom(`\^`)
+			return nil
+		}
+		t.out.WriteString("^")
+		t.atom = -1
+		t.atStart = true
+	case '$':
+		if t.basic && !t.atEnd() {
+			t.emitAtom(`\$`)
+			return nil
+		}
+		t.out.WriteString("$")
+		t.atom = -1
+		t.atStart = false
+	case '+', '?':
+		if t.basic {
+			t.emitAtom(regexp.QuoteMeta(raw))
+			return nil
+		}
+		t.repeat(raw)
+	case '{':
+		if t.basic {
+			t.emitAtom(`\{`)
+			return nil
+		}
+		return t.interval("}")
+	case '(', ')', '|':
+		if t.basic {
+			t.emitAtom(regexp.QuoteMeta(raw))
+			return nil
+		}
+		t.group(c)
+	default:
+		t.emitAtom(regexp.QuoteMeta(raw))
+	}
+	return nil
+}
+
+// escape translates the character after a backslash.
+func (t *posixTranslator) escape() error {
+	if t.pos == len(t.src) {
+		return errors.New("trailing backslash (\\)")
+	}
+	c, size := utf8.DecodeRuneInString(t.src[t.pos:])
+	raw := t.src[t.pos : t.pos+size]
+	t.pos += size
+
+	if t.basic {
+		switch c {
+		case '(', ')', '|':
+			t.group(c)
+			return nil
+		case '{':
+			return t.interval(`\}`)
+		case '+', '?':
+			t.repeat(raw)
+			return nil
+		}
+	}
+	switch {
+	case c >= '1' && c <= '9':
+		return errors.New("back-references are not supported")
+	case c == '<' || c == '>':
+		t.out.WriteString(`\b`)
+		t.atom = -1
+	case c == '`':
+		t.out.WriteString(`\A`)
+		t.atom = -1
+	case c == '\'':
+		t.out.WriteString(`\z`)
+		t.atom = -1
+	case c == 'b' || c == 'B':
+		t.out.WriteString(`\` + raw)
+		t.atom = -1
+	case c == 'w' || c == 'W' || c == 's' || c == 'S':
+		t.emitAtom(`\` + raw)
+	default:
+		t.emitAtom(regexp.QuoteMeta(raw))
+	}
+	return nil
+}
+
+// emitAtom writes s, which matches a single item that a repetition
+// operator can follow.
+func (t *posixTranslator) emitAtom(s string) {
+	t.atom = t.out.Len()
+	t.repeated = false
+	t.atStart = false
+	t.out.WriteString(s)
+}
+
+// repeat writes the repetition operator op. With nothing to repeat, it is
+// an ordinary character. A second operator on the same atom applies to the
+// first repetition, which RE2 needs to be grouped for that.
+func (t *posixTranslator) repeat(op string) {
+	if t.atStart || t.atom < 0 {
+		t.emitAtom(regexp.QuoteMeta(op))
+		return
+	}
+	if t.repeated {
+		s := t.out.String()
+		t.out.Reset()
+		t.out.WriteString(s[:t.atom] + "(?:" + s[t.atom:] + ")")
+	}
+	t.out.WriteString(op)
+	t.repeated = true
+}
+
+// group translates the grouping character c, one of '(', ')' and '|'.
+func (t *posixTranslator) group(c rune) {
+	switch c {
+	case '(':
+		t.groups = append(t.groups, t.out.Len())
+		t.atom = -1
+		t.atStart = true
+	case ')':
+		// The group is the atom that a repetition operator would follow.
+		t.atom = -1
+		if n := len(t.groups); n > 0 {
+			t.atom = t.groups[n-1]
+			t.groups = t.groups[:n-1]
+		}
+		t.repeated = false
+		t.atStart = false
+	case '|':
+		t.atom = -1
+		t.atStart = true
+	}
+	t.out.WriteRune(c)
+}
+
+// atEnd reports whether a '$' just read ends a BRE, or the group or branch
+// it is in.
+func (t *posixTranslator) atEnd() bool {
+	rest := t.src[t.pos:]
+	return rest == "" || strings.HasPrefix(rest, `\)`) || strings.HasPrefix(rest, `\|`)
+}
+
+// interval translates an interval expression such as {2,5} whose opening
+// brace has been read and which is closed by end. In an ERE, a brace that
+// does not start a valid interval is an ordinary character.
+func (t *posixTranslator) interval(end string) error {
+	body, _, found := strings.Cut(t.src[t.pos:], end)
+	min, max, hasComma := strings.Cut(body, ",")
+	valid := found && isDigits(min, hasComma) && isDigits(max, true) && (hasComma || max == "")
+	if !valid || t.atStart || t.atom < 0 {
+		if t.basic {
+			return errors.New(`invalid content of \{\}`)
+		}
+		t.emitAtom(`\{`)
+		return nil
+	}
+	t.pos += len(body) + len(end)
+	if min == "" {
+		min = "0"
+	}
+	if hasComma {
+		t.repeat("{" + min + "," + max + "}")
+	} else {
+		t.repeat("{" + min + "}")
+	}
+	return nil
+}
+
+// isDigits reports whether s is all ASCII digits; it may be empty if
+// emptyOK.
+func isDigits(s string, emptyOK bool) bool {
+	if s == "" {
+		return emptyOK
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// bracket translates a bracket expression whose '[' has been read. Inside
+// it, a backslash is an ordinary character and a ']' right after the '['
+// or "[^" is part of the set.
+func (t *posixTranslator) bracket() (string, error) {
+	var b strings.Builder
+	b.WriteByte('[')
+	if strings.HasPrefix(t.src[t.pos:], "^") {
+		b.WriteByte('^')
+		t.pos++
+	}
+	for first := true; ; first = false {
+		if t.pos >= len(t.src) {
+			return "", errors.New("unmatched [, [^, [:, [., or [=")
+		}
+		if t.src[t.pos] == ']' && !first {
+			t.pos++
+			break
+		}
+		if strings.HasPrefix(t.src[t.pos:], "[:") {
+			name, _, ok := strings.Cut(t.src[t.pos+2:], ":]")
+			if !ok || !posixClasses[name] {
+				return "", errors.New("invalid character class")
+			}
+			b.WriteString("[:" + name + ":]")
+			t.pos += len(name) + 4
+			continue
+		}
+
+		lo, err := t.bracketChar()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(lo)
+		rest := t.src[t.pos:]
+		if strings.HasPrefix(rest, "-") && len(rest) > 1 && rest[1] != ']' {
+			t.pos++
+			hi, err := t.bracketChar()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString("-" + hi)
+		}
+	}
+	b.WriteByte(']')
+	return b.String(), nil
+}
+
+// bracketChar reads one character of a bracket expression, which may be
+// written as a collating symbol [.c.] or an equivalence class [=c=], and
+// returns it escaped for an RE2 character class.
+func (t *posixTranslator) bracketChar() (string, error) {
+	rest := t.src[t.pos:]
+	if strings.HasPrefix(rest, "[.") || strings.HasPrefix(rest, "[=") {
+		delim := rest[1:2] + "]"
+		name, _, ok := strings.Cut(rest[2:], delim)
+		if !ok || utf8.RuneCountInString(name) != 1 {
+			return "", errors.New("invalid collation character")
+		}
+		t.pos += len(name) + 4
+		return escapeClassChar(name), nil
+	}
+	_, size := utf8.DecodeRuneInString(rest)
+	t.pos += size
+	return escapeClassChar(rest[:size]), nil
+}
+
+// escapeClassChar escapes c, a single character, if it is special in an
+// RE2 character class.
+func escapeClassChar(c string) string {
+	switch c {
+	case `\`, "[", "]", "^", "-":
+		return `\` + c
+	}
+	return c
+}
@@ -0,0 +1,114 @@
+package coreutils
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPosixToRE2(t *testing.T) {
+	tests := []struct {
+		expr    string
+		basic   bool
+		want    string
+		wantErr string
+	}{
+		// Basic regular expressions.
+		{expr: "abc", basic: true, want: "abc"},
+		{expr: "a.c", basic: true, want: "a.c"},
+		{expr: "a*", basic: true, want: "a*"},
+		{expr: "*a", basic: true, want: `\*a`},
+		{expr: "^*a", basic: true, want: `^\*a`},
+		{expr: "a**", basic: true, want: "(?:a*)*"},
+		{expr: `a\+`, basic: true, want: "a+"},
+		{expr: `a\?`, basic: true, want: "a?"},
+		{expr: "a+?", basic: true, want: `a\+\?`},
+		{expr: `a\*`, basic: true, want: `a\*`},
+		{expr: `\.`, basic: true, want: `\.`},
+		{expr: `\(ab\)*`, basic: true, want: "(ab)*"},
+		{expr: `\(a\|b\)`, basic: true, want: "(a|b)"},
+		{expr: "(a|b)", basic: true, want: `\(a\|b\)`},
+		{expr: `a\{2\}`, basic: true, want: "a{2}"},
+		{expr: `a\{2,3\}`, basic: true, want: "a{2,3}"},
+		{expr: `a\{,3\}`, basic: true, want: "a{0,3}"},
+		{expr: `a\{2,\}`, basic: true, want: "a{2,}"},
+		{expr: "a{2}", basic: true, want: `a\{2\}`},
+		{expr: "^a$", basic: true, want: "^a$"},
+		{expr: "a^b", basic: true, want: `a\^b`},
+		{expr: "a$b", basic: true, want: `a\$b`},
+		{expr: `\(^a$\)`, basic: true, want: "(^a$)"},
+		{expr: `a$\|^b`, basic: true, want: "a$|^b"},
+		{expr: `\<a\>`, basic: true, want: `\ba\b`},
+		{expr: `\ba\B`, basic: true, want: `\ba\B`},
+		{expr: `\w\W\s\S*`, basic: true, want: `\w\W\s\S*`},
+		{expr: "\\`a\\'", basic: true, want: `\Aa\z`},
+		{expr: "é*", basic: true, want: "é*"},
+		{expr: `a\`, basic: true, wantErr: `trailing backslash (\)`},
+		{expr: `\(a\)\1`, basic: true, wantErr: "back-references are not supported"},
+		{expr: `a\{x\}`, basic: true, wantErr: `invalid content of \{\}`},
+		{expr: `a\{2`, basic: true, wantErr: `invalid content of \{\}`},
+		{expr: `\{2\}`, basic: true, wantErr: `invalid content of \{\}`},
+
+		// Bracket expressions, the same in both syntaxes.
+		{expr: "[a-z]", basic: true, want: "[a-z]"},
+		{expr: "[]a]", basic: true, want: `[\]a]`},
+		{expr: "[^]a]", basic: true, want: `[^\]a]`},
+		{expr: `[a\]`, basic: true, want: `[a\\]`},
+		{expr: "[a-]", basic: true, want: `[a\-]`},
+		{expr: "[a^[]", basic: true, want: `[a\^\[]`},
+		{expr: "[[:digit:]x]", basic: true, want: "[[:digit:]x]"},
+		{expr: "[[.-.]a]", basic: true, want: `[\-a]`},
+		{expr: "[[=e=]]", basic: true, want: "[e]"},
+		{expr: "[[.a.]-c]", basic: true, want: "[a-c]"},
+		{expr: "[abc", basic: true, wantErr: "unmatched [, [^, [:, [., or [="},
+		{expr: "[]", basic: true, wantErr: "unmatched [, [^, [:, [., or [="},
+		{expr: "[[:foo:]]", basic: true, wantErr: "invalid character class"},
+		{expr: "[[:digit]", basic: true, wantErr: "invalid character class"},
+		{expr: "[[.ab.]]", basic: true, wantErr: "invalid collation character"},
+		{expr: "[[:alpha:]", wantErr: "unmatched [, [^, [:, [., or [="},
+
+		// Extended regular expressions.
+		{expr: "a+b?", want: "a+b?"},
+		{expr: "(a|b)+", want: "(a|b)+"},
+		{expr: "()*", want: "()*"},
+		{expr: "a{2,3}", want: "a{2,3}"},
+		{expr: "a{,3}", want: "a{0,3}"},
+		{expr: "a{x}", want: `a\{x\}`},
+		{expr: "a{1", want: `a\{1`},
+		{expr: "{2}", want: `\{2\}`},
+		{expr: "a+*", want: "(?:a+)*"},
+		{expr: "a{2}{3}", want: "(?:a{2}){3}"},
+		{expr: "(ab)+?", want: "(?:(ab)+)?"},
+		{expr: "+a", want: `\+a`},
+		{expr: "a|*b", want: `a|\*b`},
+		{expr: "(*a)", want: `(\*a)`},
+		{expr: `\(a\)`, want: `\(a\)`},
+		{expr: `a\+\{`, want: `a\+\{`},
+		{expr: "a^b$c", want: "a^b$c"},
+		{expr: `\<a|b\>`, want: `\ba|b\b`},
+		{expr: `(a)\1`, wantErr: "back-references are not supported"},
+		{expr: `a\`, wantErr: `trailing backslash (\)`},
+	}
+	for _, tt := range tests {
+		syntax := "ERE"
+		if tt.basic {
+			syntax = "BRE"
+		}
+		got, err := posixToRE2(tt.expr, tt.basic)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s %q: got %q, %v, want error %q", syntax, tt.expr, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", syntax, tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %q = %q, want %q", syntax, tt.expr, got, tt.want)
+		}
+		if _, err := regexp.Compile(got); err != nil {
+			t.Errorf("%s %q: RE2 %q does not compile: %v", syntax, tt.expr, got, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package coreutils
+
+import (
+	"context"
+	"io"
+)
+
+// HeadOptions configures Head.
+type HeadOptions struct {
+	Lines          int  // number of lines to print
+	ZeroTerminated bool // lines end with NUL instead of newline
+}
+
+// Head writes the first lines of r to w. It stops reading once it has
+// them.
+func Head(ctx context.Context, r io.Reader, w io.Writer, opts HeadOptions) error {
+	if opts.Lines <= 0 {
+		return nil
+	}
+	delim := lineDelim(opts.ZeroTerminated)
+	count := 0
+	return eachLine(ctx, r, delim, func(line string) error {
+		if err := writeLine(w, line, delim); err != nil {
+			return err
+		}
+		if count++; count >= opts.Lines {
+			return errStop
+		}
+		return nil
+	})
+}
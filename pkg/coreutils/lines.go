@@ -0,0 +1,55 @@
+package coreutils
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+)
+
+// errStop ends eachLine early without an error.
+var errStop = errors.New("stop")
+
+// lineDelim returns the byte that ends a line: NUL with zeroTerminated,
+// newline otherwise.
+func lineDelim(zeroTerminated bool) byte {
+	if zeroTerminated {
+		return 0
+	}
+	return '\n'
+}
+
+// eachLine calls fn with every line of r, without its terminator delim.
+// When fn returns errStop, eachLine stops reading and returns nil.
+func eachLine(ctx context.Context, r io.Reader, delim byte, fn func(line string) error) error {
+	scanner := utils.NewLineReader(r, delim)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(scanner.Text()); err != nil {
+			if err == errStop {
+				return nil
+			}
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readLines returns all lines of r.
+func readLines(ctx context.Context, r io.Reader, delim byte) ([]string, error) {
+	var lines []string
+	err := eachLine(ctx, r, delim, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	return lines, err
+}
+
+// writeLine writes line to w, terminated by delim.
+func writeLine(w io.Writer, line string, delim byte) error {
+	_, err := io.WriteString(w, line+string(delim))
+	return err
+}
@@ -0,0 +1,59 @@
+package coreutils
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+	"strings"
+)
+
+// PasteOptions configures Paste.
+type PasteOptions struct {
+	Delimiters string // delimiters used in turn between columns; default tab
+}
+
+// Paste writes the lines of readers side by side, one column per reader.
+// Readers that run out early contribute empty columns.
+func Paste(ctx context.Context, readers []io.Reader, w io.Writer, opts PasteOptions) error {
+	delimiters := []rune{'\t'}
+	if opts.Delimiters != "" {
+		delimiters = []rune(opts.Delimiters)
+	}
+
+	scanners := make([]*utils.LineReader, len(readers))
+	for i, r := range readers {
+		scanners[i] = utils.NewLineReader(r, '\n')
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		currentLines := make([]string, len(scanners))
+		moreData := false
+		for i, scanner := range scanners {
+			if scanner.Scan() {
+				currentLines[i] = scanner.Text()
+				moreData = true
+			} else if err := scanner.Err(); err != nil {
+				return fmt.Errorf("reading input: %v", err)
+			}
+		}
+		if !moreData {
+			return nil
+		}
+
+		var outputBuilder strings.Builder
+		for i, line := range currentLines {
+			outputBuilder.WriteString(line)
+			if i < len(currentLines)-1 {
+				outputBuilder.WriteRune(delimiters[i%len(delimiters)]) // Cycle through delimiters
+			}
+		}
+		if _, err := fmt.Fprintln(w, outputBuilder.String()); err != nil {
+			return err
+		}
+	}
+}
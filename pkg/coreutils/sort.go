@@ -0,0 +1,303 @@
+package coreutils
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// SortOptions configures Sort.
+type SortOptions struct {
+	// KeyOptions apply to the whole line when there are no Keys, and to
+	// every key given without options of its own.
+	KeyOptions
+	// Keys are compared in order; later keys only break ties of earlier
+	// ones. Without keys the whole line is the key.
+	Keys []SortKey
+	// FieldSeparator separates the fields that Keys select. When empty,
+	// each field starts at the blanks that precede it.
+	FieldSeparator string
+	// Unique outputs only the first of a run of lines with equal keys.
+	Unique bool
+	// Stable keeps lines with equal keys in input order, instead of
+	// comparing whole lines byte by byte as a last resort.
+	Stable bool
+	// ZeroTerminated makes lines end with NUL instead of newline.
+	ZeroTerminated bool
+	// BufferSize limits the memory, in bytes, that Sort holds lines in
+	// before it writes them to a temporary file. Zero means
+	// DefaultSortBufferSize; very small sizes are rounded up.
+	BufferSize int64
+	// TempDir is where Sort creates temporary files. When empty, the
+	// default directory for temporary files is used.
+	TempDir string
+	// Locale turns on Unicode collation for text keys and the last-resort
+	// comparison, following the rules of the named locale, such as "de" or
+	// "sv_SE.UTF-8". Canonically equivalent text collates equal. Empty,
+	// "C" and "POSIX" mean byte order. See CheckLocale.
+	Locale string
+	// RandomSeed salts the hashes that OrderRandom sorts by, so the same
+	// seed gives the same order. When empty, a random seed is used.
+	RandomSeed []byte
+	// Parallel is the number of goroutines that sort lines in memory.
+	// Zero means the number of CPUs, up to 8. The output does not depend
+	// on it.
+	Parallel int
+}
+
+// DefaultSortBufferSize is the memory Sort uses for lines when
+// SortOptions.BufferSize is zero.
+const DefaultSortBufferSize = 128 << 20
+
+// minSortBufferSize keeps a tiny BufferSize from writing a temporary file
+// for every line or two.
+const minSortBufferSize = 64 << 10
+
+// Sort writes the lines of r to w in sorted order. Input that does not fit
+// in opts.BufferSize is sorted in runs, which are written to temporary
+// files and merged.
+func Sort(ctx context.Context, r io.Reader, w io.Writer, opts SortOptions) error {
+	s := newSorter(opts)
+	delim := lineDelim(opts.ZeroTerminated)
+	limit := opts.BufferSize
+	if limit <= 0 {
+		limit = DefaultSortBufferSize
+	}
+	limit = max(limit, minSortBufferSize)
+
+	var (
+		chunk []sortLine
+		size  int64
+		runs  []*sortRun
+	)
+	defer func() {
+		for _, run := range runs {
+			run.remove()
+		}
+	}()
+
+	err := eachLine(ctx, r, delim, func(line string) error {
+		l := s.line(line)
+		chunk = append(chunk, l)
+		size += s.lineSize(&l)
+		if size < limit {
+			return nil
+		}
+		run, err := s.spill(chunk, opts.TempDir, delim)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+		clear(chunk)
+		chunk, size = chunk[:0], 0
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		s.sort(chunk)
+		out := s.writer(w, delim)
+		for i := range chunk {
+			if err := out.write(&chunk[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(chunk) > 0 {
+		run, err := s.spill(chunk, opts.TempDir, delim)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+		chunk = nil
+	}
+	return s.mergeRuns(ctx, &runs, w, opts.TempDir, delim)
+}
+
+// Merge writes the lines of readers, each of which must already be sorted
+// according to opts, to w in sorted order. Unlike Sort, it holds only one
+// line of each reader in memory.
+func Merge(ctx context.Context, readers []io.Reader, w io.Writer, opts SortOptions) error {
+	s := newSorter(opts)
+	return s.mergeReaders(ctx, readers, w, lineDelim(opts.ZeroTerminated))
+}
+
+// SortDisorder is the first line found out of order by CheckSorted.
+type SortDisorder struct {
+	Line int    // 1-based line number
+	Text string // the line
+}
+
+// CheckSorted reports the first line of r that sorts before the line
+// preceding it according to opts, or nil if r is sorted. With opts.Unique,
+// a line with the same keys as the one before is out of order too.
+func CheckSorted(ctx context.Context, r io.Reader, opts SortOptions) (*SortDisorder, error) {
+	s := newSorter(opts)
+	var (
+		prev     sortLine
+		disorder *SortDisorder
+	)
+	n := 0
+	err := eachLine(ctx, r, lineDelim(opts.ZeroTerminated), func(line string) error {
+		n++
+		cur := s.line(line)
+		if n > 1 {
+			c := s.compare(prev, cur)
+			if c > 0 || c == 0 && s.unique {
+				disorder = &SortDisorder{Line: n, Text: line}
+				return errStop
+			}
+		}
+		prev = cur
+		return nil
+	})
+	return disorder, err
+}
+
+// SortLines sorts lines in place according to opts and returns them. With
+// opts.Unique the returned slice may be shorter than lines.
+func SortLines(lines []string, opts SortOptions) []string {
+	s := newSorter(opts)
+	items := make([]sortLine, len(lines))
+	for i, line := range lines {
+		items[i] = s.line(line)
+	}
+
+	s.sort(items)
+
+	lines = lines[:0]
+	for i := range items {
+		if s.unique && i > 0 && s.compareKeys(&items[i-1], &items[i]) == 0 {
+			continue
+		}
+		lines = append(lines, items[i].text)
+	}
+	return lines
+}
+
+// sorter compares lines by the keys of a SortOptions.
+type sorter struct {
+	keys       []SortKey
+	sep        string
+	lastResort bool
+	reverse    bool
+	unique     bool
+	parallel   int
+	seed       uint64 // hash state for OrderRandom
+
+	// collations holds, for each key compared as collated text, its
+	// collation; lineCollation is for the last-resort comparison.
+	collations    []*collation
+	lineCollation *collation
+}
+
+// sortLine is a line with its keys prepared for comparison.
+type sortLine struct {
+	text     string
+	keys     []keyValue
+	collated string // collation key of text, for the last-resort comparison
+}
+
+func newSorter(opts SortOptions) *sorter {
+	s := &sorter{
+		keys:       opts.Keys,
+		sep:        opts.FieldSeparator,
+		lastResort: !opts.Stable && !opts.Unique,
+		reverse:    opts.Reverse,
+		unique:     opts.Unique,
+		parallel:   opts.Parallel,
+	}
+	if len(opts.RandomSeed) > 0 {
+		s.seed = seedHash(opts.RandomSeed)
+	} else {
+		s.seed = rand.Uint64()
+	}
+	if s.parallel <= 0 {
+		s.parallel = min(runtime.GOMAXPROCS(0), 8)
+	}
+	if len(s.keys) == 0 {
+		s.keys = []SortKey{{StartField: 1, KeyOptions: opts.KeyOptions}}
+	} else {
+		s.keys = slices.Clone(s.keys)
+		for i := range s.keys {
+			if s.keys[i].KeyOptions == (KeyOptions{}) && !s.keys[i].EndIgnoreBlanks {
+				s.keys[i].KeyOptions = opts.KeyOptions
+				s.keys[i].EndIgnoreBlanks = opts.IgnoreBlanks
+			}
+		}
+	}
+
+	if plain := newCollation(opts.Locale, false); plain != nil {
+		folded := newCollation(opts.Locale, true)
+		s.collations = make([]*collation, len(s.keys))
+		for i, k := range s.keys {
+			switch {
+			case k.Order != OrderText:
+			case k.FoldCase:
+				s.collations[i] = folded
+			default:
+				s.collations[i] = plain
+			}
+		}
+		if s.lastResort {
+			s.lineCollation = plain
+		}
+	}
+	return s
+}
+
+// line extracts and prepares the keys of text.
+func (s *sorter) line(text string) sortLine {
+	l := sortLine{text: text, keys: make([]keyValue, len(s.keys))}
+	for i := range s.keys {
+		k := &s.keys[i]
+		l.keys[i] = k.value(k.extract(text, s.sep))
+		if k.Order == OrderRandom {
+			l.keys[i].hash = randomHash(s.seed, l.keys[i].text)
+		}
+		if s.collations != nil && s.collations[i] != nil {
+			l.keys[i].text = s.collations[i].key(l.keys[i].text)
+		}
+	}
+	if s.lineCollation != nil {
+		l.collated = s.lineCollation.key(text)
+	}
+	return l
+}
+
+// compareKeys compares a and b by their keys only.
+func (s *sorter) compareKeys(a, b *sortLine) int {
+	for i := range s.keys {
+		k := &s.keys[i]
+		if c := k.compareValues(&a.keys[i], &b.keys[i]); c != 0 {
+			if k.Reverse {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// compare compares a and b by their keys and, unless turned off, by their
+// whole text when the keys are equal.
+func (s *sorter) compare(a, b sortLine) int {
+	if c := s.compareKeys(&a, &b); c != 0 || !s.lastResort {
+		return c
+	}
+	c := strings.Compare(a.collated, b.collated)
+	if c == 0 {
+		c = strings.Compare(a.text, b.text)
+	}
+	if s.reverse {
+		return -c
+	}
+	return c
+}
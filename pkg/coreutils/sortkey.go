@@ -0,0 +1,746 @@
+package coreutils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SortOrder is how sort compares keys.
+type SortOrder int
+
+const (
+	OrderText           SortOrder = iota // byte by byte
+	OrderNumeric                         // -n: leading decimal number
+	OrderGeneralNumeric                  // -g: floating point, with exponents, inf and nan
+	OrderHumanNumeric                    // -h: number with a size suffix such as 2K or 1G
+	OrderMonth                           // -M: JAN < FEB < ... < DEC, unknown names first
+	OrderVersion                         // -V: natural order of version numbers
+	OrderRandom                          // -R: shuffled, with equal keys kept together
+)
+
+// KeyOptions are the ordering options that can be given to sort as a whole
+// or to a single key.
+type KeyOptions struct {
+	Order             SortOrder
+	IgnoreBlanks      bool // -b: ignore leading blanks of fields
+	Dictionary        bool // -d: consider only blanks and alphanumerics
+	FoldCase          bool // -f: fold lower case to upper case
+	IgnoreNonprinting bool // -i: consider only printable characters
+	Reverse           bool // -r: reverse the result of comparisons
+}
+
+// SortKey selects the part of a line that sort compares, as given to -k.
+// Fields and characters are numbered from 1.
+type SortKey struct {
+	StartField int
+	StartChar  int // 0 is the same as 1
+	EndField   int // 0 means the end of the line
+	EndChar    int // 0 means the end of the field
+
+	// KeyOptions apply to this key only. A key without any options uses
+	// those given for the whole sort. IgnoreBlanks applies to the start
+	// of the key, and EndIgnoreBlanks to its end, as b is written after
+	// POS1 or POS2.
+	KeyOptions
+	EndIgnoreBlanks bool
+}
+
+// ParseSortKey parses a key specification in the syntax of sort -k:
+// POS1[,POS2], where each POS is F[.C][OPTS] and OPTS are any of the
+// letters bdfgiMhnRrV.
+func ParseSortKey(spec string) (SortKey, error) {
+	var key SortKey
+	start, end, hasEnd := strings.Cut(spec, ",")
+
+	field, char, rest, err := parseKeyPos(start)
+	if err != nil {
+		return key, fmt.Errorf("invalid key %q: %v", spec, err)
+	}
+	if field == 0 {
+		return key, fmt.Errorf("invalid key %q: field number is zero", spec)
+	}
+	if char == 0 && strings.Contains(start, ".") {
+		return key, fmt.Errorf("invalid key %q: character offset is zero", spec)
+	}
+	key.StartField, key.StartChar = field, char
+	if err := key.parseModifiers(rest, false); err != nil {
+		return key, fmt.Errorf("invalid key %q: %v", spec, err)
+	}
+
+	if hasEnd {
+		field, char, rest, err := parseKeyPos(end)
+		if err != nil {
+			return key, fmt.Errorf("invalid key %q: %v", spec, err)
+		}
+		if field == 0 {
+			return key, fmt.Errorf("invalid key %q: field number is zero", spec)
+		}
+		key.EndField, key.EndChar = field, char
+		if err := key.parseModifiers(rest, true); err != nil {
+			return key, fmt.Errorf("invalid key %q: %v", spec, err)
+		}
+	}
+	return key, nil
+}
+
+// parseKeyPos parses F[.C] at the start of s and returns the rest.
+func parseKeyPos(s string) (field, char int, rest string, err error) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, 0, "", fmt.Errorf("missing field number")
+	}
+	if field, err = strconv.Atoi(s[:i]); err != nil {
+		return 0, 0, "", fmt.Errorf("field number too large")
+	}
+	if i < len(s) && s[i] == '.' {
+		j := i + 1
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j == i+1 {
+			return 0, 0, "", fmt.Errorf("missing character offset")
+		}
+		if char, err = strconv.Atoi(s[i+1 : j]); err != nil {
+			return 0, 0, "", fmt.Errorf("character offset too large")
+		}
+		i = j
+	}
+	return field, char, s[i:], nil
+}
+
+// parseModifiers sets the options in mods, which follow POS2 if end is
+// set and POS1 otherwise.
+func (k *SortKey) parseModifiers(mods string, end bool) error {
+	for _, c := range mods {
+		if c == 'b' && end {
+			k.EndIgnoreBlanks = true
+			continue
+		}
+		if err := k.KeyOptions.Set(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set turns on the option written as the letter c in a key's modifiers,
+// such as 'n' for OrderNumeric. Setting two different orders is an error.
+func (o *KeyOptions) Set(c rune) error {
+	order := OrderText
+	switch c {
+	case 'b':
+		o.IgnoreBlanks = true
+	case 'd':
+		o.Dictionary = true
+	case 'f':
+		o.FoldCase = true
+	case 'i':
+		o.IgnoreNonprinting = true
+	case 'r':
+		o.Reverse = true
+	case 'n':
+		order = OrderNumeric
+	case 'g':
+		order = OrderGeneralNumeric
+	case 'h':
+		order = OrderHumanNumeric
+	case 'M':
+		order = OrderMonth
+	case 'V':
+		order = OrderVersion
+	case 'R':
+		order = OrderRandom
+	default:
+		return fmt.Errorf("unknown modifier '%c'", c)
+	}
+	if order != OrderText {
+		if o.Order != OrderText && o.Order != order {
+			return fmt.Errorf("options '-%c%c' are incompatible", o.Order.letter(), c)
+		}
+		o.Order = order
+	}
+	return nil
+}
+
+func (o SortOrder) letter() rune {
+	return rune(" nghMVR"[o])
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// fieldStart returns the offset in line where the 1-based field n starts.
+// Without a separator, fields are separated by the empty string between a
+// non-blank and a blank, so every field but the first starts with blanks.
+func fieldStart(line, sep string, n int) int {
+	pos := 0
+	for ; n > 1 && pos < len(line); n-- {
+		if sep != "" {
+			i := strings.Index(line[pos:], sep)
+			if i < 0 {
+				return len(line)
+			}
+			pos += i + len(sep)
+			continue
+		}
+		for pos < len(line) && isBlank(line[pos]) {
+			pos++
+		}
+		for pos < len(line) && !isBlank(line[pos]) {
+			pos++
+		}
+	}
+	if n > 1 {
+		return len(line)
+	}
+	return pos
+}
+
+// fieldEnd returns the offset in line where the field starting at pos ends.
+func fieldEnd(line, sep string, pos int) int {
+	if sep != "" {
+		if i := strings.Index(line[pos:], sep); i >= 0 {
+			return pos + i
+		}
+		return len(line)
+	}
+	for pos < len(line) && isBlank(line[pos]) {
+		pos++
+	}
+	for pos < len(line) && !isBlank(line[pos]) {
+		pos++
+	}
+	return pos
+}
+
+func skipBlanks(line string, pos int) int {
+	for pos < len(line) && isBlank(line[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// extract returns the part of line that k selects.
+func (k *SortKey) extract(line, sep string) string {
+	start := fieldStart(line, sep, k.StartField)
+	if k.IgnoreBlanks {
+		start = skipBlanks(line, start)
+	}
+	if k.StartChar > 1 {
+		start = min(len(line), start+k.StartChar-1)
+	}
+
+	end := len(line)
+	if k.EndField > 0 {
+		end = fieldStart(line, sep, k.EndField)
+		if k.EndChar == 0 {
+			end = fieldEnd(line, sep, end)
+		} else {
+			if k.EndIgnoreBlanks {
+				end = skipBlanks(line, end)
+			}
+			end = min(len(line), end+k.EndChar)
+		}
+	}
+
+	if end <= start {
+		return ""
+	}
+	return line[start:end]
+}
+
+// keyValue is a key extracted from a line and prepared for comparison
+// according to its key's order.
+type keyValue struct {
+	text string // the key, filtered and folded for OrderText, OrderVersion and OrderRandom
+	hash uint64 // OrderRandom: the key's salted hash
+
+	// OrderNumeric and OrderHumanNumeric: the number's sign and digits,
+	// without leading zeros of the integer part or trailing zeros of the
+	// fraction, and the size suffix as a rank.
+	neg      bool
+	intPart  string
+	fracPart string
+	rank     int
+
+	// OrderGeneralNumeric: the value and whether it is a number (2), NaN
+	// (1) or not a number at all (0). OrderMonth: the month in rank.
+	float float64
+	class int
+}
+
+// value prepares key, extracted from a line, for comparison under opts.
+func (opts *KeyOptions) value(key string) keyValue {
+	switch opts.Order {
+	case OrderNumeric:
+		v, _ := parseDecimal(key)
+		return v
+	case OrderHumanNumeric:
+		v, end := parseDecimal(key)
+		v.rank = humanRank(v, key[end:])
+		return v
+	case OrderGeneralNumeric:
+		f, ok := parseFloatPrefix(key)
+		switch {
+		case !ok:
+			return keyValue{class: 0}
+		case math.IsNaN(f):
+			return keyValue{class: 1}
+		default:
+			return keyValue{class: 2, float: f}
+		}
+	case OrderMonth:
+		return keyValue{rank: monthRank(key)}
+	default:
+		return keyValue{text: opts.filter(key)}
+	}
+}
+
+// filter applies -d, -i and -f to key. They work on Unicode characters;
+// bytes that are not valid UTF-8 are kept as they are.
+func (opts *KeyOptions) filter(key string) string {
+	if !opts.Dictionary && !opts.IgnoreNonprinting && !opts.FoldCase {
+		return key
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); {
+		r, size := utf8.DecodeRuneInString(key[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteByte(key[i])
+			i++
+			continue
+		}
+		i += size
+		if opts.Dictionary && !(r == ' ' || r == '\t' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			continue
+		}
+		if opts.IgnoreNonprinting && !unicode.IsPrint(r) {
+			continue
+		}
+		if opts.FoldCase {
+			r = unicode.ToUpper(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseDecimal reads the number at the start of s, after blanks, the way
+// sort -n does: an optional minus sign, digits and an optional fraction.
+// Anything else counts as zero. It also returns where the number ends.
+func parseDecimal(s string) (keyValue, int) {
+	var v keyValue
+	i := skipBlanks(s, 0)
+	if i < len(s) && s[i] == '-' {
+		v.neg = true
+		i++
+	}
+	start := i
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	v.intPart = strings.TrimLeft(s[start:i], "0")
+	if i < len(s) && s[i] == '.' {
+		start = i + 1
+		i++
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+		v.fracPart = strings.TrimRight(s[start:i], "0")
+	}
+	if v.intPart == "" && v.fracPart == "" {
+		v.neg = false // -0 is 0
+	}
+	return v, i
+}
+
+// compareDecimal compares two numbers read by parseDecimal.
+func compareDecimal(a, b *keyValue) int {
+	if a.neg != b.neg {
+		if a.neg {
+			return -1
+		}
+		return 1
+	}
+	c := len(a.intPart) - len(b.intPart)
+	if c == 0 {
+		c = strings.Compare(a.intPart, b.intPart)
+	}
+	if c == 0 {
+		c = strings.Compare(a.fracPart, b.fracPart)
+	}
+	if a.neg {
+		return -c
+	}
+	return c
+}
+
+const humanSuffixes = "KMGTPEZYRQ"
+
+// humanRank ranks the size suffix at the start of suffix, which follows
+// the number v: 0 for zero, otherwise 1 without a suffix, 2 for K and so
+// on, negated for negative numbers.
+func humanRank(v keyValue, suffix string) int {
+	if v.intPart == "" && v.fracPart == "" {
+		return 0
+	}
+	rank := 1
+	if suffix != "" {
+		c := suffix[0]
+		if c == 'k' {
+			c = 'K'
+		}
+		if i := strings.IndexByte(humanSuffixes, c); i >= 0 {
+			rank = i + 2
+		}
+	}
+	if v.neg {
+		return -rank
+	}
+	return rank
+}
+
+// parseFloatPrefix reads the longest floating point number at the start
+// of s, after blanks, including exponents, "inf", "nan" and hexadecimal
+// numbers such as 0x1.8p3, as strtold does.
+func parseFloatPrefix(s string) (float64, bool) {
+	s = s[skipBlanks(s, 0):]
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	lower := strings.ToLower(s[i:])
+	for _, word := range []string{"infinity", "inf", "nan"} {
+		if strings.HasPrefix(lower, word) {
+			f, err := strconv.ParseFloat(s[:i+len(word)], 64)
+			return f, err == nil
+		}
+	}
+	if f, ok := parseHexFloatPrefix(s, i); ok {
+		return f, true
+	}
+
+	digits, nonzero := 0, false
+	for i < len(s) && isDigit(s[i]) {
+		nonzero = nonzero || s[i] != '0'
+		i++
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for i < len(s) && isDigit(s[i]) {
+			nonzero = nonzero || s[i] != '0'
+			i++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0, false
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			i = j
+		}
+	}
+	return parseFloatInRange(s[:i], nonzero)
+}
+
+// parseHexFloatPrefix reads a hexadecimal floating point number starting
+// with "0x" at s[i], after the sign, such as 0xA, 0x1.8 or 0x1p-3. Unlike
+// strconv, strtold does not need the binary exponent.
+func parseHexFloatPrefix(s string, i int) (float64, bool) {
+	if len(s) < i+2 || s[i] != '0' || s[i+1] != 'x' && s[i+1] != 'X' {
+		return 0, false
+	}
+	j := i + 2
+	digits, nonzero := 0, false
+	for j < len(s) && isHexDigit(s[j]) {
+		nonzero = nonzero || s[j] != '0'
+		j++
+		digits++
+	}
+	if j < len(s) && s[j] == '.' {
+		j++
+		for j < len(s) && isHexDigit(s[j]) {
+			nonzero = nonzero || s[j] != '0'
+			j++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0, false // just the 0 before the x
+	}
+
+	exp := "p0"
+	if j < len(s) && (s[j] == 'p' || s[j] == 'P') {
+		k := j + 1
+		if k < len(s) && (s[k] == '+' || s[k] == '-') {
+			k++
+		}
+		if k < len(s) && isDigit(s[k]) {
+			for k < len(s) && isDigit(s[k]) {
+				k++
+			}
+			exp, j = "", k
+		}
+	}
+	return parseFloatInRange(s[:j]+exp, nonzero)
+}
+
+// parseFloatInRange parses num, a number strconv accepts; nonzero tells
+// whether any of its digits is. GNU sort reads numbers as long doubles,
+// which reach further than float64, so values beyond its range come back
+// as the smallest or largest float64 rather than as 0 or ±Inf.
+func parseFloatInRange(num string, nonzero bool) (float64, bool) {
+	f, err := strconv.ParseFloat(num, 64)
+	switch {
+	case math.IsInf(f, 0):
+		f = math.Copysign(math.MaxFloat64, f)
+	case f == 0 && nonzero:
+		f = math.SmallestNonzeroFloat64
+		if num[0] == '-' {
+			f = -f
+		}
+	case err != nil:
+		return 0, false
+	}
+	return f, true
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+var monthNames = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+// monthRank returns 1 to 12 for a key starting with a month name, after
+// blanks and in any case, and 0 otherwise.
+func monthRank(s string) int {
+	s = s[skipBlanks(s, 0):]
+	if len(s) < 3 {
+		return 0
+	}
+	prefix := strings.ToUpper(s[:3])
+	for i, name := range monthNames {
+		if prefix == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// compareValues compares two keys prepared by value under opts.
+func (opts *KeyOptions) compareValues(a, b *keyValue) int {
+	switch opts.Order {
+	case OrderNumeric:
+		return compareDecimal(a, b)
+	case OrderHumanNumeric:
+		if a.rank != b.rank {
+			return cmpInt(a.rank, b.rank)
+		}
+		return compareDecimal(a, b)
+	case OrderGeneralNumeric:
+		if a.class != b.class || a.class != 2 {
+			return cmpInt(a.class, b.class)
+		}
+		switch {
+		case a.float < b.float:
+			return -1
+		case a.float > b.float:
+			return 1
+		}
+		return 0
+	case OrderMonth:
+		return cmpInt(a.rank, b.rank)
+	case OrderVersion:
+		return compareVersions(a.text, b.text)
+	case OrderRandom:
+		if a.hash != b.hash {
+			if a.hash < b.hash {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.text, b.text)
+	default:
+		return strings.Compare(a.text, b.text)
+	}
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareVersions compares a and b as version strings with the filevercmp
+// rules of GNU sort -V. The empty string comes first, then ".", "..",
+// other names starting with "." and everything else. File suffixes such
+// as ".tar.gz" only count when the rest of the names are equal, so that
+// foo-1.2.tar.gz sorts before foo-1.10.zip.
+func compareVersions(a, b string) int {
+	if a == "" || b == "" {
+		return cmpInt(len(a), len(b))
+	}
+	if a[0] == '.' || b[0] == '.' {
+		if c := cmpInt(dotRank(a), dotRank(b)); c != 0 {
+			return c
+		}
+	}
+
+	ap, bp := versionPrefixLen(a), versionPrefixLen(b)
+	if c := compareVersionParts(a[:ap], b[:bp]); c != 0 || ap == len(a) && bp == len(b) {
+		return c
+	}
+	return compareVersionParts(a, b)
+}
+
+// dotRank ranks names for compareVersions: 0 for ".", 1 for "..", 2 for
+// other names starting with "." and 3 for the rest.
+func dotRank(s string) int {
+	switch {
+	case s == ".":
+		return 0
+	case s == "..":
+		return 1
+	case s[0] == '.':
+		return 2
+	}
+	return 3
+}
+
+// versionPrefixLen returns the length of s without its file suffix, the
+// longest run at its end of a "." and a letter or "~" followed by letters,
+// digits and "~", as in ".tar.gz". A name like ".bashrc" is all suffix.
+func versionPrefixLen(s string) int {
+	prefix, i := 0, 0
+	for {
+		for i+1 < len(s) && s[i] == '.' && (isASCIILetter(s[i+1]) || s[i+1] == '~') {
+			i += 2
+			for i < len(s) && (isASCIILetter(s[i]) || isDigit(s[i]) || s[i] == '~') {
+				i++
+			}
+		}
+		if i == len(s) {
+			return prefix
+		}
+		i++
+		prefix = i
+	}
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// compareVersionParts compares a and b as versions: runs of digits compare
+// as numbers and other characters compare with letters before punctuation
+// and "~" before anything, even the end.
+func compareVersionParts(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := versionOrder(a, i), versionOrder(b, j)
+			if ac != bc {
+				return cmpInt(ac, bc)
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		firstDiff := 0
+		for i < len(a) && j < len(b) && isDigit(a[i]) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = cmpInt(int(a[i]), int(b[j]))
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// versionOrder is the weight of s[i] in the non-digit parts of a version.
+func versionOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	c := s[i]
+	switch {
+	case isDigit(c):
+		return 0
+	case isASCIILetter(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// seedHash returns the state of a hash that has read seed, for randomHash.
+func seedHash(seed []byte) uint64 {
+	h := uint64(fnvOffset)
+	for _, c := range seed {
+		h = (h ^ uint64(c)) * fnvPrime
+	}
+	return h
+}
+
+// randomHash hashes key after the seed that produced state, for -R. FNV-1a
+// is finished with the splitmix64 mixer so that keys differing only at
+// the end still land far apart.
+func randomHash(state uint64, key string) uint64 {
+	h := state
+	for i := 0; i < len(key); i++ {
+		h = (h ^ uint64(key[i])) * fnvPrime
+	}
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
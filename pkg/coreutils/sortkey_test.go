@@ -0,0 +1,180 @@
+package coreutils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseSortKey(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    SortKey
+		wantErr bool
+	}{
+		{spec: "2", want: SortKey{StartField: 2}},
+		{spec: "2,3", want: SortKey{StartField: 2, EndField: 3}},
+		{spec: "1.3,1.5", want: SortKey{StartField: 1, StartChar: 3, EndField: 1, EndChar: 5}},
+		{spec: "1,2.0", want: SortKey{StartField: 1, EndField: 2}},
+		{spec: "2n", want: SortKey{StartField: 2, KeyOptions: KeyOptions{Order: OrderNumeric}}},
+		{spec: "2,2nr", want: SortKey{StartField: 2, EndField: 2, KeyOptions: KeyOptions{Order: OrderNumeric, Reverse: true}}},
+		{spec: "3b,3", want: SortKey{StartField: 3, EndField: 3, KeyOptions: KeyOptions{IgnoreBlanks: true}}},
+		{spec: "2,2.3b", want: SortKey{StartField: 2, EndField: 2, EndChar: 3, EndIgnoreBlanks: true}},
+		{spec: "2b,2b", want: SortKey{StartField: 2, EndField: 2, KeyOptions: KeyOptions{IgnoreBlanks: true}, EndIgnoreBlanks: true}},
+		{spec: "1.2dfi", want: SortKey{StartField: 1, StartChar: 2, KeyOptions: KeyOptions{Dictionary: true, FoldCase: true, IgnoreNonprinting: true}}},
+		{spec: "2M,2M", want: SortKey{StartField: 2, EndField: 2, KeyOptions: KeyOptions{Order: OrderMonth}}},
+		{spec: "1V", want: SortKey{StartField: 1, KeyOptions: KeyOptions{Order: OrderVersion}}},
+		{spec: "1h", want: SortKey{StartField: 1, KeyOptions: KeyOptions{Order: OrderHumanNumeric}}},
+		{spec: "1gR", wantErr: true},
+		{spec: "2n,2g", wantErr: true},
+		{spec: "", wantErr: true},
+		{spec: "0", wantErr: true},
+		{spec: "1.0", wantErr: true},
+		{spec: "a", wantErr: true},
+		{spec: "1.", wantErr: true},
+		{spec: "1,", wantErr: true},
+		{spec: "1,0", wantErr: true},
+		{spec: "1,2.", wantErr: true},
+		{spec: "1x", wantErr: true},
+		{spec: "99999999999999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSortKey(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSortKey(%q) = %+v, want an error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSortKey(%q): %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSortKey(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestSortKeyExtract(t *testing.T) {
+	tests := []struct {
+		spec, sep, line string
+		want            string
+	}{
+		// Without a separator, fields after the first keep their leading
+		// blanks unless b is given.
+		{"1", "", "  foo bar baz", "  foo bar baz"},
+		{"1,1", "", "  foo bar baz", "  foo"},
+		{"2", "", "  foo bar baz", " bar baz"},
+		{"2,2", "", "  foo bar baz", " bar"},
+		{"2b,2", "", "  foo bar baz", "bar"},
+		{"2.2,2", "", "  foo bar baz", "bar"},
+		{"2.2b,2", "", "  foo bar baz", "ar"},
+		{"1.3,1.4", "", "  foo bar baz", "fo"},
+		{"2,2.3", "", "a \t bcdef", " \t "},
+		{"2,2.3b", "", "a \t bcdef", " \t bcd"},
+		{"2b,2.3", "", "a \t bcdef", ""},
+		{"2b,2.3b", "", "a \t bcdef", "bcd"},
+		{"1.10,1", "", "abc def", ""},
+		{"4", "", "  foo bar baz", ""},
+		{"3,2", "", "  foo bar baz", ""},
+		{"1", "", "", ""},
+
+		{"2,2", ":", "a:b::d", "b"},
+		{"3,3", ":", "a:b::d", ""},
+		{"2,3", ":", "a:b::d", "b:"},
+		{"2", ":", "a:b::d", "b::d"},
+		{"4", ":", "a:b::d", "d"},
+		{"5", ":", "a:b::d", ""},
+		{"1.2,1", ":", "abc:d", "bc"},
+		{"2,2", ":", "a: b :c", " b "},
+		{"2b,2", ":", "a: b :c", "b "},
+		{"2,2", "::", "a::b::c", "b"},
+	}
+	for _, tt := range tests {
+		key, err := ParseSortKey(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSortKey(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := key.extract(tt.line, tt.sep); got != tt.want {
+			t.Errorf("-k %s -t %q on %q = %q, want %q", tt.spec, tt.sep, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	// Each pair is in the order GNU sort -V puts it.
+	tests := []struct{ a, b string }{
+		{"", "."},
+		{".", ".."},
+		{"..", ".a"},
+		{".a", "..a"},
+		{".z", "..a"},
+		{"..a", ".1"},
+		{".~", ".a"},
+		{".bashrc", "a"},
+		{"a", "a."},
+		{"x", "x.a"},
+		{"1.9", "1.10"},
+		{"1.0~rc1", "1.0"},
+		{"a.tar.gz", "a1.tar.gz"},
+		{"foo-1.2.tar.gz", "foo-1.10.zip"},
+		{"foo-1.2.tar", "foo-1.2.tar.gz"},
+		{"x..a", "x.1"},
+		{"x.z", "x..a"},
+		{"1.02", "1.3"},
+	}
+	for _, tt := range tests {
+		if c := compareVersions(tt.a, tt.b); c >= 0 {
+			t.Errorf("compareVersions(%q, %q) = %d, want < 0", tt.a, tt.b, c)
+		}
+		if c := compareVersions(tt.b, tt.a); c <= 0 {
+			t.Errorf("compareVersions(%q, %q) = %d, want > 0", tt.b, tt.a, c)
+		}
+	}
+	for _, s := range []string{"", ".", "..", ".a", "foo-1.2.tar.gz"} {
+		if c := compareVersions(s, s); c != 0 {
+			t.Errorf("compareVersions(%q, %q) = %d, want 0", s, s, c)
+		}
+	}
+}
+
+func TestParseFloatPrefix(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"8", 8, true},
+		{" -2.5e1x", -25, true},
+		{"1e", 1, true},
+		{"0x1p3", 8, true},
+		{"0xA", 10, true},
+		{"0X10", 16, true},
+		{"0x1.8p1", 3, true},
+		{"-0x1p-2", -0.25, true},
+		{"0x.8", 0.5, true},
+		{"0x1p", 1, true},
+		{"0x", 0, true},
+		{"0xg", 0, true},
+		{"inf", math.Inf(1), true},
+		{"-Infinity", math.Inf(-1), true},
+		{"1e400", math.MaxFloat64, true},
+		{"-0x1p1024", -math.MaxFloat64, true},
+		{"1e-400", math.SmallestNonzeroFloat64, true},
+		{"-0x1p-1080", -math.SmallestNonzeroFloat64, true},
+		{"0e-400", 0, true},
+		{"abc", 0, false},
+		{"", 0, false},
+		{"-", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseFloatPrefix(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseFloatPrefix(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+	if f, ok := parseFloatPrefix("nan"); !ok || !math.IsNaN(f) {
+		t.Errorf("parseFloatPrefix(%q) = %v, %v, want NaN, true", "nan", f, ok)
+	}
+}
@@ -0,0 +1,270 @@
+package coreutils
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"io"
+	"os"
+	"unsafe"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+)
+
+// sortMergeFanIn is the most runs merged at once. With more runs than
+// that, groups of runs are merged into longer runs first, so the number of
+// open files stays bounded.
+const sortMergeFanIn = 64
+
+// sortRun is a temporary file holding sorted lines. It is only open while
+// it is being merged.
+type sortRun struct {
+	name string
+	file *os.File
+}
+
+// createRun creates an empty run in dir.
+func createRun(dir string) (*sortRun, error) {
+	f, err := os.CreateTemp(dir, "sort")
+	if err != nil {
+		return nil, err
+	}
+	return &sortRun{name: f.Name(), file: f}, nil
+}
+
+// finish flushes bw, which writes to r, and closes r.
+func (r *sortRun) finish(bw *bufio.Writer) error {
+	err := bw.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	return err
+}
+
+func (r *sortRun) close() {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+func (r *sortRun) remove() {
+	r.close()
+	os.Remove(r.name)
+}
+
+// lineSize estimates the memory l takes up.
+func (s *sorter) lineSize(l *sortLine) int64 {
+	size := int64(unsafe.Sizeof(*l)) + int64(len(l.text)) + int64(len(l.collated))
+	size += int64(len(l.keys)) * int64(unsafe.Sizeof(keyValue{}))
+	for i := range l.keys {
+		if len(l.keys[i].text) != 0 && !s.sharesText(i) {
+			size += int64(len(l.keys[i].text))
+		}
+	}
+	return size
+}
+
+// sharesText reports whether the text of key i is a part of the line
+// rather than a copy.
+func (s *sorter) sharesText(i int) bool {
+	k := &s.keys[i]
+	if s.collations != nil && s.collations[i] != nil {
+		return false
+	}
+	return !k.Dictionary && !k.IgnoreNonprinting && !k.FoldCase
+}
+
+// spill sorts lines and writes them to a new run in dir.
+func (s *sorter) spill(lines []sortLine, dir string, delim byte) (*sortRun, error) {
+	s.sort(lines)
+
+	run, err := createRun(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	bw := bufio.NewWriterSize(run.file, 64*1024)
+	out := s.writer(bw, delim)
+	for i := range lines {
+		if err = out.write(&lines[i]); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = run.finish(bw)
+	}
+	if err != nil {
+		run.remove()
+		return nil, err
+	}
+	return run, nil
+}
+
+// mergeRuns merges the runs, in order, to w. Runs merged into longer ones
+// are removed and replaced in *runs.
+func (s *sorter) mergeRuns(ctx context.Context, runs *[]*sortRun, w io.Writer, dir string, delim byte) error {
+	for len(*runs) > sortMergeFanIn {
+		var next []*sortRun
+		for len(*runs) > 0 {
+			group := (*runs)[:min(sortMergeFanIn, len(*runs))]
+			merged, err := s.mergeRun(ctx, group, dir, delim)
+			if err != nil {
+				*runs = append(next, *runs...)
+				return err
+			}
+			for _, run := range group {
+				run.remove()
+			}
+			next = append(next, merged)
+			*runs = (*runs)[len(group):]
+		}
+		*runs = next
+	}
+	return s.merge(ctx, *runs, w, delim)
+}
+
+// mergeRun merges runs into a new run in dir.
+func (s *sorter) mergeRun(ctx context.Context, runs []*sortRun, dir string, delim byte) (*sortRun, error) {
+	merged, err := createRun(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	bw := bufio.NewWriterSize(merged.file, 64*1024)
+	err = s.merge(ctx, runs, bw, delim)
+	if err == nil {
+		err = merged.finish(bw)
+	}
+	if err != nil {
+		merged.remove()
+		return nil, err
+	}
+	return merged, nil
+}
+
+// merge writes the lines of runs to w in sorted order.
+func (s *sorter) merge(ctx context.Context, runs []*sortRun, w io.Writer, delim byte) error {
+	defer func() {
+		for _, run := range runs {
+			run.close()
+		}
+	}()
+
+	readers := make([]io.Reader, len(runs))
+	for i, run := range runs {
+		f, err := os.Open(run.name)
+		if err != nil {
+			return err
+		}
+		run.file = f
+		readers[i] = f
+	}
+	return s.mergeReaders(ctx, readers, w, delim)
+}
+
+// mergeReaders writes the lines of the sorted readers to w in sorted
+// order. Equal lines come out in the order of their readers, so merging
+// stable runs is stable.
+func (s *sorter) mergeReaders(ctx context.Context, readers []io.Reader, w io.Writer, delim byte) error {
+	h := &mergeHeap{sorter: s}
+	for i, r := range readers {
+		c := &mergeCursor{run: i, lines: utils.NewLineReader(r, delim)}
+		ok, err := s.advance(c)
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.cursors = append(h.cursors, c)
+		}
+	}
+	heap.Init(h)
+
+	out := s.writer(w, delim)
+	for h.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c := h.cursors[0]
+		if err := out.write(&c.line); err != nil {
+			return err
+		}
+		ok, err := s.advance(c)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
+
+// mergeCursor is the position of a merge in one run.
+type mergeCursor struct {
+	run   int
+	lines *utils.LineReader
+	line  sortLine
+}
+
+// advance reads the next line of c's run, reporting false at its end.
+func (s *sorter) advance(c *mergeCursor) (bool, error) {
+	if !c.lines.Scan() {
+		return false, c.lines.Err()
+	}
+	c.line = s.line(c.lines.Text())
+	return true, nil
+}
+
+// mergeHeap orders cursors by their current line, then by run.
+type mergeHeap struct {
+	sorter  *sorter
+	cursors []*mergeCursor
+}
+
+func (h *mergeHeap) Len() int { return len(h.cursors) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.cursors[i], h.cursors[j]
+	if c := h.sorter.compare(a.line, b.line); c != 0 {
+		return c < 0
+	}
+	return a.run < b.run
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+
+func (h *mergeHeap) Push(x any) { h.cursors = append(h.cursors, x.(*mergeCursor)) }
+
+func (h *mergeHeap) Pop() any {
+	c := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return c
+}
+
+// sortWriter writes sorted lines, dropping lines whose keys equal those of
+// the line before when the sort is unique.
+type sortWriter struct {
+	sorter *sorter
+	w      io.Writer
+	delim  byte
+	prev   sortLine
+	wrote  bool
+}
+
+func (s *sorter) writer(w io.Writer, delim byte) *sortWriter {
+	return &sortWriter{sorter: s, w: w, delim: delim}
+}
+
+func (sw *sortWriter) write(l *sortLine) error {
+	if sw.sorter.unique {
+		if sw.wrote && sw.sorter.compareKeys(&sw.prev, l) == 0 {
+			return nil
+		}
+		sw.prev, sw.wrote = *l, true
+	}
+	return writeLine(sw.w, l.text, sw.delim)
+}
@@ -0,0 +1,111 @@
+package coreutils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSortRuns(t *testing.T) {
+	// Lines with a small number of keys, so that many are equal and
+	// stability shows, in mixed case for -f.
+	rng := rand.New(rand.NewPCG(3, 4))
+	words := []string{"apple", "Apple", "banana", "BANANA", "cherry", "date", "Date"}
+	var b strings.Builder
+	lines := 60000
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, "%d\t%s\t%d\n", rng.IntN(50), words[rng.IntN(len(words))], i)
+	}
+	input := b.String()
+
+	key := func(spec string) SortKey {
+		k, err := ParseSortKey(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	tests := []struct {
+		name string
+		opts SortOptions
+	}{
+		{"whole line", SortOptions{}},
+		{"reverse fold case", SortOptions{KeyOptions: KeyOptions{Reverse: true, FoldCase: true}}},
+		{"stable numeric key", SortOptions{Keys: []SortKey{key("1,1n")}, FieldSeparator: "\t", Stable: true}},
+		{"stable two keys", SortOptions{Keys: []SortKey{key("2,2f"), key("1,1nr")}, FieldSeparator: "\t", Stable: true}},
+		{"unique key", SortOptions{Keys: []SortKey{key("2,2")}, FieldSeparator: "\t", Unique: true}},
+		{"stable unique numeric", SortOptions{Keys: []SortKey{key("1,1n")}, FieldSeparator: "\t", Stable: true, Unique: true}},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		small := tt.opts
+		small.BufferSize = minSortBufferSize
+		small.TempDir = dir
+
+		// Make sure the input takes more runs than are merged at once, so
+		// that runs are also merged into longer runs first.
+		s := newSorter(small)
+		var size int64
+		for _, line := range strings.SplitAfter(input, "\n") {
+			l := s.line(strings.TrimSuffix(line, "\n"))
+			size += s.lineSize(&l)
+		}
+		if runs := size / minSortBufferSize; runs <= sortMergeFanIn {
+			t.Fatalf("%s: input makes only %d runs", tt.name, runs)
+		}
+
+		var want, got bytes.Buffer
+		if err := Sort(context.Background(), strings.NewReader(input), &want, tt.opts); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := Sort(context.Background(), strings.NewReader(input), &got, small); err != nil {
+			t.Fatalf("%s: with runs: %v", tt.name, err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s: output with temporary runs differs from the in-memory sort", tt.name)
+		}
+
+		left, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(left) > 0 {
+			t.Errorf("%s: %d temporary files left behind", tt.name, len(left))
+		}
+	}
+}
+
+func TestSortRunsStable(t *testing.T) {
+	// With -s, equal keys keep their input order across runs: the second
+	// field is the line's position, so it must ascend within each key.
+	var b strings.Builder
+	for i := 0; i < 40000; i++ {
+		fmt.Fprintf(&b, "%d %d\n", i%7, i)
+	}
+	opts := SortOptions{
+		Keys:       []SortKey{{StartField: 1, EndField: 1, KeyOptions: KeyOptions{Order: OrderNumeric}}},
+		Stable:     true,
+		BufferSize: minSortBufferSize,
+		TempDir:    t.TempDir(),
+	}
+	var out bytes.Buffer
+	if err := Sort(context.Background(), strings.NewReader(b.String()), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	lastKey, lastPos := -1, -1
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		var key, pos int
+		if _, err := fmt.Sscanf(line, "%d %d", &key, &pos); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		if key < lastKey || key == lastKey && pos < lastPos {
+			t.Fatalf("line %q out of order after key %d, position %d", line, lastKey, lastPos)
+		}
+		lastKey, lastPos = key, pos
+	}
+}
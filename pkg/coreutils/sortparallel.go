@@ -0,0 +1,78 @@
+package coreutils
+
+import (
+	"slices"
+	"sync"
+)
+
+// minParallelSortLines is the fewest lines each goroutine of a parallel
+// sort gets; below that, starting goroutines costs more than it saves.
+const minParallelSortLines = 16 << 10
+
+// sort sorts lines stably. Large inputs are split into contiguous parts
+// that are sorted at the same time and then merged pairwise, the earlier
+// part winning ties, so the result is the same as sorting in one go.
+func (s *sorter) sort(lines []sortLine) {
+	parts := min(s.parallel, len(lines)/minParallelSortLines)
+	if parts <= 1 {
+		slices.SortStableFunc(lines, s.compare)
+		return
+	}
+
+	bounds := make([]int, parts+1)
+	for i := range bounds {
+		bounds[i] = i * len(lines) / parts
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < parts; i++ {
+		wg.Add(1)
+		go func(part []sortLine) {
+			defer wg.Done()
+			slices.SortStableFunc(part, s.compare)
+		}(lines[bounds[i]:bounds[i+1]])
+	}
+	wg.Wait()
+
+	src, dst := lines, make([]sortLine, len(lines))
+	for len(bounds) > 2 {
+		var next []int
+		for i := 0; i < len(bounds)-1; i += 2 {
+			lo := bounds[i]
+			next = append(next, lo)
+			if i+2 >= len(bounds) {
+				copy(dst[lo:], src[lo:])
+				continue
+			}
+			mid, hi := bounds[i+1], bounds[i+2]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.mergeSorted(dst[lo:hi], src[lo:mid], src[mid:hi])
+			}()
+		}
+		wg.Wait()
+		bounds = append(next, len(lines))
+		src, dst = dst, src
+	}
+	if &src[0] != &lines[0] {
+		copy(lines, src)
+	}
+}
+
+// mergeSorted merges the sorted a and b into dst, taking from a on ties.
+func (s *sorter) mergeSorted(dst, a, b []sortLine) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if s.compare(b[j], a[i]) < 0 {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
@@ -0,0 +1,104 @@
+package coreutils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sortInput returns n lines of a number from a small range, so that many
+// keys are equal, followed by the line's position in the input.
+func sortInput(n int) []string {
+	rng := rand.New(rand.NewPCG(1, 2))
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%d %d", rng.IntN(1000), i)
+	}
+	return lines
+}
+
+func TestSortParallelStable(t *testing.T) {
+	// Enough lines for 8 goroutines, each with more than
+	// minParallelSortLines, and an odd number of parts with 3.
+	lines := sortInput(8*minParallelSortLines + 123)
+	input := strings.Join(lines, "\n") + "\n"
+
+	want := append([]string(nil), lines...)
+	key := func(line string) int {
+		n, _ := strconv.Atoi(line[:strings.IndexByte(line, ' ')])
+		return n
+	}
+	sort.SliceStable(want, func(i, j int) bool { return key(want[i]) < key(want[j]) })
+	wantOut := strings.Join(want, "\n") + "\n"
+
+	for _, parallel := range []int{1, 2, 3, 8} {
+		opts := SortOptions{
+			Keys:     []SortKey{{StartField: 1, EndField: 1, KeyOptions: KeyOptions{Order: OrderNumeric}}},
+			Stable:   true,
+			Parallel: parallel,
+		}
+		var out bytes.Buffer
+		if err := Sort(context.Background(), strings.NewReader(input), &out, opts); err != nil {
+			t.Fatalf("parallel %d: %v", parallel, err)
+		}
+		if out.String() != wantOut {
+			t.Errorf("parallel %d: output differs from a stable sort", parallel)
+		}
+	}
+}
+
+func TestSortParallelSameOutput(t *testing.T) {
+	lines := sortInput(4*minParallelSortLines + 7)
+	input := strings.Join(lines, "\n") + "\n"
+
+	tests := []SortOptions{
+		{},
+		{KeyOptions: KeyOptions{Reverse: true}},
+		{Keys: []SortKey{{StartField: 1, EndField: 1, KeyOptions: KeyOptions{Order: OrderNumeric}}}},
+		{Keys: []SortKey{{StartField: 1, EndField: 1}}, Unique: true},
+	}
+	for i, opts := range tests {
+		var want string
+		for _, parallel := range []int{1, 2, 3, 4, 8} {
+			opts.Parallel = parallel
+			var out bytes.Buffer
+			if err := Sort(context.Background(), strings.NewReader(input), &out, opts); err != nil {
+				t.Fatalf("options %d, parallel %d: %v", i, parallel, err)
+			}
+			if parallel == 1 {
+				want = out.String()
+			} else if out.String() != want {
+				t.Errorf("options %d: parallel %d output differs from parallel 1", i, parallel)
+			}
+		}
+	}
+}
+
+var (
+	benchSortOnce  sync.Once
+	benchSortInput string
+)
+
+func BenchmarkSortParallel(b *testing.B) {
+	benchSortOnce.Do(func() {
+		benchSortInput = strings.Join(sortInput(2_000_000), "\n") + "\n"
+	})
+	for _, parallel := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("parallel=%d", parallel), func(b *testing.B) {
+			opts := SortOptions{BufferSize: 1 << 30, Parallel: parallel}
+			b.SetBytes(int64(len(benchSortInput)))
+			for i := 0; i < b.N; i++ {
+				if err := Sort(context.Background(), strings.NewReader(benchSortInput), io.Discard, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package coreutils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SplitOptions configures Split. Exactly one of Lines and Bytes should be
+// set; when neither is, pieces of 1000 lines are written.
+type SplitOptions struct {
+	Prefix          string // output file name prefix; default "x"
+	Dir             string // directory for the pieces; default the current directory
+	Lines           int64  // lines per piece
+	Bytes           int64  // bytes per piece
+	NumericSuffixes bool   // use 00, 01, ... instead of aa, ab, ...
+}
+
+// Split writes r into a series of files named Prefix followed by a suffix.
+func Split(ctx context.Context, r io.Reader, opts SplitOptions) error {
+	if opts.Prefix == "" {
+		opts.Prefix = "x"
+	}
+	if opts.Lines > 0 && opts.Bytes > 0 {
+		return fmt.Errorf("cannot split by lines and bytes simultaneously")
+	}
+	if opts.Bytes > 0 {
+		return splitByBytes(ctx, r, opts)
+	}
+	if opts.Lines <= 0 {
+		opts.Lines = 1000
+	}
+	return splitByLines(ctx, r, opts)
+}
+
+func (opts SplitOptions) pieceName(index int) string {
+	return filepath.Join(opts.Dir, opts.Prefix+splitSuffix(index, opts.NumericSuffixes))
+}
+
+func splitSuffix(index int, numeric bool) string {
+	if numeric {
+		return fmt.Sprintf("%02d", index) // Pad with leading zeros for consistency
+	}
+	// Mimic classic 'aa', 'ab', 'ac'... suffixes
+	const alphabet = "abcdefghijklmnopqrstuvwxyz"
+	suf := ""
+	for {
+		suf = string(alphabet[index%len(alphabet)]) + suf
+		index = index / len(alphabet)
+		if index == 0 {
+			break
+		}
+		index-- // Adjust for 0-based indexing after division
+	}
+	return suf
+}
+
+func splitByLines(ctx context.Context, r io.Reader, opts SplitOptions) error {
+	fileIndex := 0
+	currentLineCount := int64(0)
+	var outputFile *os.File
+	var outputWriter *bufio.Writer
+
+	closeOutput := func() error {
+		if outputFile == nil {
+			return nil
+		}
+		err := outputWriter.Flush()
+		if cerr := outputFile.Close(); err == nil {
+			err = cerr
+		}
+		outputFile = nil
+		return err
+	}
+	defer closeOutput()
+
+	err := eachLine(ctx, r, '\n', func(line string) error {
+		if currentLineCount == 0 {
+			if err := closeOutput(); err != nil {
+				return fmt.Errorf("writing to output file: %v", err)
+			}
+			f, err := os.Create(opts.pieceName(fileIndex))
+			if err != nil {
+				return fmt.Errorf("creating output file: %v", err)
+			}
+			outputFile, outputWriter = f, bufio.NewWriter(f)
+			fileIndex++
+		}
+
+		if _, err := outputWriter.WriteString(line + "\n"); err != nil {
+			return fmt.Errorf("writing to output file: %v", err)
+		}
+		currentLineCount++
+
+		if currentLineCount >= opts.Lines {
+			currentLineCount = 0 // Reset for the next file
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := closeOutput(); err != nil {
+		return fmt.Errorf("writing to output file: %v", err)
+	}
+	return nil
+}
+
+func splitByBytes(ctx context.Context, r io.Reader, opts SplitOptions) error {
+	fileIndex := 0
+	buffer := make([]byte, 4096) // Use a common buffer size
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := opts.pieceName(fileIndex)
+		outputFile, err := os.Create(name)
+		if err != nil {
+			return fmt.Errorf("creating output file: %v", err)
+		}
+		fileIndex++
+
+		bytesReadThisFile := int64(0)
+		eof := false
+		for bytesReadThisFile < opts.Bytes {
+			bytesToRead := min(int64(len(buffer)), opts.Bytes-bytesReadThisFile)
+
+			n, err := r.Read(buffer[:bytesToRead])
+			if n > 0 {
+				if _, writeErr := outputFile.Write(buffer[:n]); writeErr != nil {
+					outputFile.Close()
+					return fmt.Errorf("writing to output file: %v", writeErr)
+				}
+				bytesReadThisFile += int64(n)
+			}
+			if err == io.EOF {
+				eof = true
+				break // End of input
+			}
+			if err != nil {
+				outputFile.Close()
+				return fmt.Errorf("reading input: %v", err)
+			}
+		}
+
+		if err := outputFile.Close(); err != nil {
+			return fmt.Errorf("closing output file: %v", err)
+		}
+		// Don't leave an empty piece behind when the input ran out exactly
+		// at a boundary.
+		if bytesReadThisFile == 0 {
+			os.Remove(name)
+		}
+
+		if eof {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package coreutils
+
+import (
+	"context"
+	"io"
+)
+
+// TailOptions configures Tail.
+type TailOptions struct {
+	Lines          int  // number of lines to print
+	ZeroTerminated bool // lines end with NUL instead of newline
+}
+
+// Tail writes the last lines of r to w, keeping only those lines in
+// memory.
+func Tail(ctx context.Context, r io.Reader, w io.Writer, opts TailOptions) error {
+	n := opts.Lines
+	if n < 0 {
+		n = 0
+	}
+
+	ring := make([]string, n)
+	count := 0
+	delim := lineDelim(opts.ZeroTerminated)
+	err := eachLine(ctx, r, delim, func(line string) error {
+		if n > 0 {
+			ring[count%n] = line
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var last []string
+	if count < n {
+		last = ring[:count]
+	} else if n > 0 {
+		start := count % n
+		last = append(ring[start:], ring[:start]...)
+	}
+
+	for _, line := range last {
+		if err := writeLine(w, line, delim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package coreutils
+
+import (
+	"io"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+)
+
+// DecodeText returns a reader that drops a UTF-8 byte order mark, converts
+// UTF-16 with a byte order mark to UTF-8 and, unless keepCR is set, turns
+// CRLF line endings into LF. The other functions read lines as they are,
+// so wrap input from Windows tools with it first.
+func DecodeText(r io.Reader, keepCR bool) io.Reader {
+	return utils.NewTextReader(r, keepCR)
+}
@@ -0,0 +1,98 @@
+package coreutils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TrOptions configures Tr.
+type TrOptions struct {
+	Set1       string
+	Set2       string // replacement characters; unused with Delete
+	Delete     bool   // delete characters in Set1 instead of translating
+	Complement bool   // use the complement of Set1
+}
+
+// Tr copies r to w, translating or deleting characters. Sets may contain
+// ranges such as a-z. When Set2 is shorter than Set1, its last character
+// is repeated; when it is empty, characters of Set1 are deleted. With
+// Complement, as in GNU tr, the characters not in Set1 are the ones
+// deleted, or translated to the last character of Set2.
+func Tr(ctx context.Context, r io.Reader, w io.Writer, opts TrOptions) error {
+	set1 := expandCharSet(opts.Set1)
+	set2 := expandCharSet(opts.Set2)
+
+	bw := bufio.NewWriter(w)
+	br := bufio.NewReader(r)
+	for {
+		c, _, err := br.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading input: %v", err)
+		}
+		if c == '\n' {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		char := string(c)
+		var processedChar string
+
+		inSet1 := strings.ContainsRune(set1, c)
+		if opts.Delete {
+			if inSet1 == opts.Complement {
+				processedChar = char
+			}
+		} else if opts.Complement {
+			// Every character outside Set1 maps to the last one of Set2.
+			processedChar = char
+			if !inSet1 && len(set2) > 0 {
+				processedChar = string(set2[len(set2)-1])
+			} else if !inSet1 {
+				processedChar = ""
+			}
+		} else {
+			idx := strings.IndexRune(set1, c)
+			if idx != -1 {
+				if idx < len(set2) {
+					processedChar = string(set2[idx])
+				} else if len(set2) > 0 {
+					processedChar = string(set2[len(set2)-1])
+				}
+			} else {
+				processedChar = char
+			}
+		}
+		if _, err := bw.WriteString(processedChar); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// expandCharSet expands character ranges like 'a-z' into a full string of characters.
+func expandCharSet(set string) string {
+	var expanded []rune
+	for i := 0; i < len(set); i++ {
+		if i+2 < len(set) && set[i+1] == '-' {
+			start := rune(set[i])
+			end := rune(set[i+2])
+			if start > end {
+				start, end = end, start
+			}
+			for r := start; r <= end; r++ {
+				expanded = append(expanded, r)
+			}
+			i += 2 // Skip the character after '-'
+		} else {
+			expanded = append(expanded, rune(set[i]))
+		}
+	}
+	return string(expanded)
+}
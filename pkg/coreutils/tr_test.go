@@ -0,0 +1,34 @@
+package coreutils
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTr(t *testing.T) {
+	tests := []struct {
+		name string
+		opts TrOptions
+		in   string
+		want string
+	}{
+		{"translate", TrOptions{Set1: "a-c", Set2: "x"}, "abcdef\n", "xxxdef\n"},
+		{"translate pairs", TrOptions{Set1: "abc", Set2: "xy"}, "abcdef\n", "xyydef\n"},
+		{"delete", TrOptions{Set1: "a-c", Delete: true}, "abcdef\n", "def\n"},
+		{"complement translate", TrOptions{Set1: "a-c", Set2: "x", Complement: true}, "abcdef\n", "abcxxxx"},
+		{"complement translate last of set2", TrOptions{Set1: "a-c", Set2: "xyz", Complement: true}, "abcd", "abcz"},
+		{"complement delete", TrOptions{Set1: "a-c", Delete: true, Complement: true}, "abcdef\n", "abc"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := Tr(context.Background(), strings.NewReader(tt.in), &out, tt.opts); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, out.String(), tt.want)
+		}
+	}
+}
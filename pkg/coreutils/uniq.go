@@ -0,0 +1,275 @@
+package coreutils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+)
+
+// UniqDelimit says where Uniq writes empty lines between the groups of
+// equal lines printed with AllRepeated or Group.
+type UniqDelimit int
+
+const (
+	UniqDelimitNone     UniqDelimit = iota // no empty lines; only for AllRepeated
+	UniqDelimitSeparate                    // between groups
+	UniqDelimitPrepend                     // before every group
+	UniqDelimitAppend                      // after every group; only for Group
+	UniqDelimitBoth                        // before and after every group; only for Group
+)
+
+// UniqOptions configures Uniq.
+type UniqOptions struct {
+	Count    bool // prefix lines with their number of occurrences
+	Repeated bool // print only lines that occur more than once
+	Unique   bool // print only lines that occur exactly once
+	// AllRepeated prints every line of each group of lines that occurs
+	// more than once, rather than just the first. With Unique, the last
+	// line of each group is left out, as GNU uniq -u -D does.
+	AllRepeated bool
+	// Group prints every line, with the groups of equal lines set apart by
+	// empty lines.
+	Group bool
+	// Delimit places the empty lines for AllRepeated and Group.
+	Delimit UniqDelimit
+	// Global collapses equal lines wherever they occur in the input, not
+	// only when they are adjacent. Lines are printed in the order they
+	// first appear, and every distinct line is held in memory.
+	Global bool
+
+	// Lines are compared after skipping SkipFields fields, each a run of
+	// blanks followed by non-blanks, then SkipChars characters, and then
+	// only up to CheckChars characters if it is positive.
+	SkipFields int
+	SkipChars  int
+	CheckChars int
+	// IgnoreCase compares lines without regard to case.
+	IgnoreCase bool
+
+	// ZeroTerminated makes lines end with NUL instead of newline.
+	ZeroTerminated bool
+	// Locale compares lines by Unicode collation for the named locale, as
+	// SortOptions.Locale does, so canonically equivalent lines such as the
+	// NFC and NFD forms of "café" count as the same.
+	Locale string
+}
+
+// Uniq writes r to w with each run of adjacent equal lines collapsed into
+// its first line, reading r a line at a time. With opts.Global, equal lines
+// are collapsed even when they are not adjacent.
+func Uniq(ctx context.Context, r io.Reader, w io.Writer, opts UniqOptions) error {
+	u := &uniqer{w: w, opts: opts, delim: lineDelim(opts.ZeroTerminated), key: uniqKey(opts)}
+	if opts.Global {
+		return u.global(ctx, r)
+	}
+
+	err := eachLine(ctx, r, u.delim, func(line string) error {
+		return u.add(line, u.key(line), 1)
+	})
+	if err != nil {
+		return err
+	}
+	return u.end()
+}
+
+// uniqer collapses runs of lines with the same key.
+type uniqer struct {
+	w     io.Writer
+	opts  UniqOptions
+	delim byte
+	key   func(line string) string
+
+	first    string // first line of the current group
+	firstKey string
+	last     string // line added last to the current group
+	count    int    // lines in the current group so far
+	printed  int    // groups printed so far
+}
+
+// add adds n occurrences of line, whose key is key, after the lines added
+// before. Lines are only printed as they come with AllRepeated and Group,
+// which need n to be 1.
+func (u *uniqer) add(line, key string, n int) error {
+	if u.count > 0 && key == u.firstKey {
+		u.count += n
+		err := u.more(line)
+		u.last = line
+		return err
+	}
+	if u.count > 0 {
+		if err := u.endGroup(); err != nil {
+			return err
+		}
+	}
+	u.first, u.firstKey, u.count, u.last = line, key, n, line
+	return u.start()
+}
+
+// start deals with the first line of a group.
+func (u *uniqer) start() error {
+	if !u.opts.Group {
+		return nil
+	}
+	if u.printed > 0 || u.opts.Delimit == UniqDelimitPrepend || u.opts.Delimit == UniqDelimitBoth {
+		if err := u.write(""); err != nil {
+			return err
+		}
+	}
+	u.printed++
+	return u.write(u.first)
+}
+
+// more deals with a further line of the current group. With AllRepeated
+// and Unique, it prints the line before instead, as that line has just
+// turned out not to be the last of the group.
+func (u *uniqer) more(line string) error {
+	switch {
+	case u.opts.Group:
+		return u.write(line)
+	case !u.opts.AllRepeated:
+		return nil
+	case u.count > 2 && u.opts.Unique:
+		return u.write(u.last)
+	case u.count > 2:
+		return u.write(line)
+	}
+
+	// The group has just turned out to be repeated.
+	if u.opts.Delimit == UniqDelimitPrepend || u.opts.Delimit == UniqDelimitSeparate && u.printed > 0 {
+		if err := u.write(""); err != nil {
+			return err
+		}
+	}
+	u.printed++
+	if err := u.write(u.first); err != nil || u.opts.Unique {
+		return err
+	}
+	return u.write(line)
+}
+
+// endGroup deals with the end of the current group.
+func (u *uniqer) endGroup() error {
+	if u.opts.Group || u.opts.AllRepeated || !shouldPrintLine(u.count, u.opts.Repeated, u.opts.Unique) {
+		return nil
+	}
+	line := u.first
+	if u.opts.Count {
+		line = fmt.Sprintf("%7d %s", u.count, line)
+	}
+	return u.write(line)
+}
+
+// end deals with the end of the input.
+func (u *uniqer) end() error {
+	if u.count == 0 {
+		return nil
+	}
+	if err := u.endGroup(); err != nil {
+		return err
+	}
+	if u.opts.Group && (u.opts.Delimit == UniqDelimitAppend || u.opts.Delimit == UniqDelimitBoth) {
+		return u.write("")
+	}
+	return nil
+}
+
+func (u *uniqer) write(line string) error {
+	return writeLine(u.w, line, u.delim)
+}
+
+// global reads all of r, gathering equal lines, and then adds each group's
+// lines in turn, in the order the groups first appeared.
+func (u *uniqer) global(ctx context.Context, r io.Reader) error {
+	type group struct {
+		key   string
+		lines []string // all the lines if they are printed, otherwise the first
+		count int
+	}
+	keepAll := u.opts.AllRepeated || u.opts.Group
+
+	var groups []*group
+	index := make(map[string]*group)
+	err := eachLine(ctx, r, u.delim, func(line string) error {
+		k := u.key(line)
+		g, ok := index[k]
+		if !ok {
+			g = &group{key: k}
+			index[k] = g
+			groups = append(groups, g)
+		}
+		if !ok || keepAll {
+			g.lines = append(g.lines, line)
+		}
+		g.count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		if keepAll {
+			for _, line := range g.lines {
+				if err := u.add(line, g.key, 1); err != nil {
+					return err
+				}
+			}
+		} else if err := u.add(g.lines[0], g.key, g.count); err != nil {
+			return err
+		}
+	}
+	return u.end()
+}
+
+// uniqKey returns the function that maps a line to the value lines are
+// compared by.
+func uniqKey(opts UniqOptions) func(line string) string {
+	coll := newCollation(opts.Locale, opts.IgnoreCase)
+	fold := cases.Fold()
+	return func(line string) string {
+		line = skipUniqFields(line, opts.SkipFields)
+		line = skipChars(line, opts.SkipChars)
+		if opts.CheckChars > 0 {
+			line = line[:len(line)-len(skipChars(line, opts.CheckChars))]
+		}
+		switch {
+		case coll != nil:
+			return coll.key(line)
+		case opts.IgnoreCase:
+			return fold.String(line)
+		}
+		return line
+	}
+}
+
+// skipUniqFields returns line without its first n fields, each a run of
+// blanks followed by a run of non-blanks.
+func skipUniqFields(line string, n int) string {
+	pos := 0
+	for ; n > 0 && pos < len(line); n-- {
+		pos = fieldEnd(line, "", pos)
+	}
+	return line[pos:]
+}
+
+// skipChars returns s without its first n characters.
+func skipChars(s string, n int) string {
+	for ; n > 0 && s != ""; n-- {
+		_, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+	}
+	return s
+}
+
+func shouldPrintLine(count int, showRepeated, showUnique bool) bool {
+	if showRepeated && count == 1 {
+		return false // Skip unique lines if only repeated are requested
+	}
+	if showUnique && count > 1 {
+		return false // Skip repeated lines if only unique are requested
+	}
+	return true
+}
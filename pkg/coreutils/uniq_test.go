@@ -0,0 +1,46 @@
+package coreutils
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUniqAllRepeated(t *testing.T) {
+	const in = "a\na\nb\nc\nc\nc\nd\ne\ne\n"
+
+	// The wanted output is what GNU uniq prints for the same options.
+	tests := []struct {
+		name string
+		opts UniqOptions
+		want string
+	}{
+		{"-D", UniqOptions{AllRepeated: true}, "a\na\nc\nc\nc\ne\ne\n"},
+		{"-D -u", UniqOptions{AllRepeated: true, Unique: true}, "a\nc\nc\ne\n"},
+		{"-D -d -u", UniqOptions{AllRepeated: true, Repeated: true, Unique: true}, "a\nc\nc\ne\n"},
+		{"--all-repeated=separate -u", UniqOptions{AllRepeated: true, Unique: true, Delimit: UniqDelimitSeparate}, "a\n\nc\nc\n\ne\n"},
+		{"--all-repeated=prepend -u", UniqOptions{AllRepeated: true, Unique: true, Delimit: UniqDelimitPrepend}, "\na\n\nc\nc\n\ne\n"},
+		{"-u", UniqOptions{Unique: true}, "b\nd\n"},
+		{"-d", UniqOptions{Repeated: true}, "a\nc\ne\n"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		if err := Uniq(context.Background(), strings.NewReader(in), &out, tt.opts); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Global gathers equal lines that are apart before grouping them.
+	var out strings.Builder
+	opts := UniqOptions{AllRepeated: true, Unique: true, Global: true}
+	if err := Uniq(context.Background(), strings.NewReader("a\nb\na\na\nc\nb\n"), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "a\na\nb\n"; got != want {
+		t.Errorf("--global -D -u: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package coreutils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// WcCounts holds the counts for one input.
+type WcCounts struct {
+	Name  string // shown after the counts; empty for unnamed input
+	Lines int    // number of line terminators
+	Words int    // number of maximal runs of non-space characters
+	Bytes int
+}
+
+// WcOptions selects the columns printed by WriteWc. When none is set,
+// all three are printed.
+type WcOptions struct {
+	Lines bool
+	Words bool
+	Bytes bool
+	// ZeroTerminated makes Wc count NUL-terminated lines, and WriteWc end
+	// its rows with NUL.
+	ZeroTerminated bool
+}
+
+// Wc returns the line, word and byte counts of r. Lines are counted by
+// their newline, or NUL with opts.ZeroTerminated, and words are maximal
+// runs of characters other than spaces and the line terminator.
+func Wc(ctx context.Context, r io.Reader, opts WcOptions) (WcCounts, error) {
+	var counts WcCounts
+	delim := rune(lineDelim(opts.ZeroTerminated))
+	br := bufio.NewReader(r)
+	inWord := false
+	for {
+		c, size, err := br.ReadRune()
+		if err == io.EOF {
+			return counts, nil
+		}
+		if err != nil {
+			return counts, err
+		}
+		counts.Bytes += size
+		if c == delim {
+			counts.Lines++
+			if err := ctx.Err(); err != nil {
+				return counts, err
+			}
+		}
+		if unicode.IsSpace(c) || c == delim {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			counts.Words++
+		}
+	}
+}
+
+// WriteWc writes one aligned row per entry of counts, followed by a total
+// row when there is more than one.
+func WriteWc(w io.Writer, counts []WcCounts, opts WcOptions) error {
+	if !opts.Lines && !opts.Words && !opts.Bytes {
+		opts = WcOptions{Lines: true, Words: true, Bytes: true}
+	}
+
+	total := WcCounts{Name: "total"}
+	for _, c := range counts {
+		total.Lines += c.Lines
+		total.Words += c.Words
+		total.Bytes += c.Bytes
+	}
+
+	// The totals are the largest numbers, so they set the column widths.
+	linesWidth := len(fmt.Sprintf("%d", total.Lines))
+	wordsWidth := len(fmt.Sprintf("%d", total.Words))
+	bytesWidth := len(fmt.Sprintf("%d", total.Bytes))
+
+	rows := counts
+	if len(counts) > 1 {
+		rows = append(rows[:len(rows):len(rows)], total)
+	}
+
+	for _, count := range rows {
+		columns := []string{}
+		if opts.Lines {
+			columns = append(columns, fmt.Sprintf("%*d", linesWidth, count.Lines))
+		}
+		if opts.Words {
+			columns = append(columns, fmt.Sprintf("%*d", wordsWidth, count.Words))
+		}
+		if opts.Bytes {
+			columns = append(columns, fmt.Sprintf("%*d", bytesWidth, count.Bytes))
+		}
+		if count.Name != "" {
+			columns = append(columns, count.Name)
+		}
+		if err := writeLine(w, strings.Join(columns, " "), lineDelim(opts.ZeroTerminated)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package coreutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/monster0506/bashutils-go/internal/utils"
+)
+
+// Exit statuses returned by Xargs, following GNU findutils.
+const (
+	XargsCommandFailed   = 123 // an invocation exited with status 1-125
+	XargsCommandExit255  = 124 // an invocation exited with status 255
+	XargsCommandSignaled = 125 // an invocation was killed by a signal
+	XargsCannotRun       = 126 // the command could not be run
+	XargsNotFound        = 127 // the command was not found
+)
+
+// XargsOptions configures Xargs.
+type XargsOptions struct {
+	MaxArgs      int    // at most this many items per invocation; 0 means all
+	Replace      string // run once per item, replacing this string in the command
+	Null         bool   // items are terminated by NUL
+	Delimiter    string // items are terminated by this character
+	NoRunIfEmpty bool   // do not run the command when there are no items
+	Verbose      bool   // print each command line to Stderr before running it
+
+	// Self is the executable run for a command named "bashutils". It is
+	// started with the subcommand as its argv[0], the way multi-call
+	// binaries are, and defaults to the running executable.
+	Self string
+
+	Stdin  io.Reader // standard input of the commands
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Xargs reads items from r and runs command with them appended, as the
+// xargs utility does. Without a command, "bashutils echo" is run. It
+// returns the xargs exit status (0 or one of the Xargs* constants) and an
+// error only if r could not be read.
+func Xargs(ctx context.Context, r io.Reader, command []string, opts XargsOptions) (int, error) {
+	if opts.Stderr == nil {
+		opts.Stderr = io.Discard
+	}
+	if opts.Self == "" {
+		opts.Self, _ = os.Executable()
+	}
+
+	items, err := readXargsItems(r, opts.Null, opts.Delimiter)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(items) == 0 {
+		if opts.NoRunIfEmpty || len(command) == 0 {
+			return 0, nil
+		}
+		// Without -r the command still runs once, with no items.
+		return opts.run(ctx, command, nil), nil
+	}
+
+	if len(command) == 0 {
+		command = []string{"bashutils", "echo"}
+	}
+
+	if opts.Verbose {
+		fmt.Fprintf(opts.Stderr, "About to execute with commandArgs: %v\n", command)
+	}
+	if opts.MaxArgs <= 0 {
+		return opts.run(ctx, command, items), nil
+	}
+
+	// Split items into chunks of MaxArgs
+	status := 0
+	for i := 0; i < len(items); i += opts.MaxArgs {
+		end := min(i+opts.MaxArgs, len(items))
+		s := opts.run(ctx, command, items[i:end])
+		if s > XargsCommandFailed {
+			return s, nil
+		}
+		status = max(status, s)
+	}
+	return status, nil
+}
+
+func readXargsItems(r io.Reader, nullTerminated bool, delimiter string) ([]string, error) {
+	var items []string
+
+	if nullTerminated {
+		delimiter = "\x00"
+	}
+	if delimiter != "" {
+		// Read items separated by the delimiter
+		lr := utils.NewLineReader(r, delimiter[0])
+		for lr.Scan() {
+			items = append(items, strings.TrimSpace(lr.Text()))
+		}
+		return items, lr.Err()
+	}
+
+	// Read items separated by whitespace/newlines
+	lr := utils.NewLineReader(r, '\n')
+	for lr.Scan() {
+		items = append(items, strings.Fields(lr.Text())...)
+	}
+	return items, lr.Err()
+}
+
+// run runs command with args and returns the xargs exit status for the
+// invocations. Statuses above XargsCommandFailed mean xargs must stop.
+func (opts *XargsOptions) run(ctx context.Context, command []string, args []string) int {
+	if opts.Replace == "" {
+		finalArgs := append(append([]string{}, command...), args...)
+		if opts.Verbose {
+			fmt.Fprintf(opts.Stderr, "Final command: %v\n", finalArgs)
+		}
+		return opts.runOne(ctx, finalArgs)
+	}
+
+	// Run once per item, with the replace string substituted
+	status := 0
+	for _, arg := range args {
+		replaced := strings.ReplaceAll(strings.Join(command, " "), opts.Replace, arg)
+		cmdParts := strings.Fields(replaced)
+		if len(cmdParts) > 0 {
+			s := opts.runOne(ctx, cmdParts)
+			if s > XargsCommandFailed {
+				return s
+			}
+			status = max(status, s)
+		}
+	}
+	return status
+}
+
+func (opts *XargsOptions) runOne(ctx context.Context, args []string) int {
+	if opts.Verbose {
+		fmt.Fprintf(opts.Stderr, "Executing: %s\n", strings.Join(args, " "))
+	}
+
+	name := args[0]
+	path := name
+	if name == "bashutils" && len(args) > 1 && opts.Self != "" {
+		// Run the bashutils subcommand through our own executable
+		args = args[1:]
+		name, path = args[0], opts.Self
+	}
+
+	proc := exec.CommandContext(ctx, path, args[1:]...)
+	proc.Args[0] = name
+	proc.Stdin = opts.Stdin
+	proc.Stdout = opts.Stdout
+	proc.Stderr = opts.Stderr
+
+	return opts.status(name, proc.Run())
+}
+
+// status maps the result of running name to an xargs exit status,
+// reporting the failures that xargs stops on.
+func (opts *XargsOptions) status(name string, err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		switch code := exitErr.ExitCode(); {
+		case code == -1:
+			fmt.Fprintf(opts.Stderr, "xargs: %s: terminated by signal\n", name)
+			return XargsCommandSignaled
+		case code == 255:
+			fmt.Fprintf(opts.Stderr, "xargs: %s: exited with status 255; aborting\n", name)
+			return XargsCommandExit255
+		default:
+			return XargsCommandFailed
+		}
+	case errors.Is(err, exec.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		fmt.Fprintf(opts.Stderr, "xargs: %s: No such file or directory\n", name)
+		return XargsNotFound
+	default:
+		fmt.Fprintf(opts.Stderr, "xargs: %s: %v\n", name, err)
+		return XargsCannotRun
+	}
+}
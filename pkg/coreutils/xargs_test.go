@@ -0,0 +1,34 @@
+package coreutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadXargsItemsLongLine(t *testing.T) {
+	word := strings.Repeat("w", 1<<20)
+	line := strings.TrimSuffix(strings.Repeat(word+" ", 5), " ")
+	want := []string{word, word, word, word, word, "last"}
+
+	tests := []struct {
+		name           string
+		in             string
+		nullTerminated bool
+		delimiter      string
+	}{
+		{"whitespace", line + "\nlast\n", false, ""},
+		{"NUL", strings.ReplaceAll(line, " ", "\x00") + "\x00last", true, ""},
+		{"delimiter", strings.ReplaceAll(line, " ", ",") + ",last\n", false, ","},
+	}
+	for _, tt := range tests {
+		got, err := readXargsItems(strings.NewReader(tt.in), tt.nullTerminated, tt.delimiter)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %d items, want %d", tt.name, len(got), len(want))
+		}
+	}
+}